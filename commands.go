@@ -0,0 +1,236 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ibm-silvergate/netcomposer/netModel"
+	"github.com/ibm-silvergate/netcomposer/netSpec"
+	"github.com/ibm-silvergate/netcomposer/templates"
+	yaml "gopkg.in/yaml.v2"
+)
+
+//commands are run as netcomposer <command> [args], netcomposer without a command generates a network
+var commands = map[string]func(args []string){
+	"templates":   templatesCommand,
+	"render-spec": renderSpecCommand,
+	"schema":      schemaCommand,
+	"migrate":     migrateCommand,
+	"init":        initCommand,
+	"graph":       graphCommand,
+}
+
+//runCommand runs a command and reports whether it was found
+func runCommand(name string, args []string) bool {
+	command, found := commands[name]
+	if !found {
+		return false
+	}
+	command(args)
+	return true
+}
+
+func templatesCommand(args []string) {
+	if len(args) == 0 || args[0] != "export" {
+		fmt.Fprintln(os.Stderr, "usage: netcomposer templates export [-output dir] [-force]")
+		os.Exit(1)
+	}
+
+	flags := flag.NewFlagSet("templates export", flag.ExitOnError)
+	output := flags.String("output", "templates", "directory where stock templates are exported")
+	force := flags.Bool("force", false, "replace templates already present in the output directory")
+	flags.Parse(args[1:])
+
+	written, skipped, err := templates.Export(*output, *force)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting templates: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, name := range skipped {
+		fmt.Printf("Warning: %s already exists, skipped\r\n", name)
+	}
+	fmt.Printf("Exported %d templates to %s\n", len(written), *output)
+}
+
+//renderSpecCommand prints a spec composed with its base specs and overlays, once defaults are set
+func renderSpecCommand(args []string) {
+	var overlays, overrides stringsFlag
+	flags := flag.NewFlagSet("render-spec", flag.ExitOnError)
+	specFile := flags.String("spec", "", "spec file e.g. samplenet.yaml")
+	flags.Var(&overlays, "overlay", "spec file merged on top of the spec, can be repeated")
+	flags.Var(&overrides, "set", "spec value override addressed by YAML path e.g. orderer.batch.timeout=5s, can be repeated")
+	flags.Parse(args)
+
+	if *specFile == "" {
+		fmt.Fprintln(os.Stderr, "spec file must be specified")
+		os.Exit(1)
+	}
+
+	spec, err := netSpec.LoadFromFiles(*specFile, overlays, overrides)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading network spec file: %v\n", err)
+		os.Exit(1)
+	}
+	spec.SetDefaults()
+
+	content, err := yaml.Marshal(spec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error rendering network spec: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(string(content))
+
+	if err := spec.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Network spec is NOT valid: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+//schemaCommand prints the JSON Schema of spec files
+func schemaCommand(args []string) {
+	flags := flag.NewFlagSet("schema", flag.ExitOnError)
+	output := flags.String("output", "", "file where the schema is written instead of the standard output")
+	flags.Parse(args)
+
+	content, err := json.MarshalIndent(netSpec.JSONSchema(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating schema: %v\n", err)
+		os.Exit(1)
+	}
+	content = append(content, '\n')
+
+	if *output == "" {
+		os.Stdout.Write(content)
+		return
+	}
+	if err := ioutil.WriteFile(*output, content, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing schema: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+//migrateCommand rewrites a spec file in the latest format, the files it extends or includes are migrated separately
+func migrateCommand(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	specFile := flags.String("spec", "", "spec file e.g. samplenet.yaml")
+	output := flags.String("output", "", "file where the migrated spec is written instead of replacing the spec file")
+	flags.Parse(args)
+
+	if *specFile == "" {
+		fmt.Fprintln(os.Stderr, "spec file must be specified")
+		os.Exit(1)
+	}
+
+	content, migration, err := netSpec.MigrateFile(*specFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	upToDate := migration.From == migration.To && len(migration.Changes) == 0
+	if upToDate && *output == "" {
+		fmt.Printf("%s is already %s\n", *specFile, migration.To)
+		return
+	}
+
+	target := *output
+	if target == "" {
+		target = *specFile
+	}
+	if err := ioutil.WriteFile(target, content, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing migrated spec: %v\n", err)
+		os.Exit(1)
+	}
+
+	if upToDate {
+		fmt.Printf("%s is already %s, written to %s\n", *specFile, migration.To, target)
+		return
+	}
+	fmt.Printf("Migrated %s from %s to %s\n", *specFile, migration.From, migration.To)
+	for _, change := range migration.Changes {
+		fmt.Printf("  %s\n", change)
+	}
+}
+
+//graphTemplates render the network topology in each graph format
+var graphTemplates = map[string]string{
+	"dot":     "graph-template.dot",
+	"mermaid": "graph-template.mmd",
+}
+
+//graphCommand prints the topology of a network: organizations, nodes, channel membership and chaincode deployment
+func graphCommand(args []string) {
+	var overlays, overrides stringsFlag
+	flags := flag.NewFlagSet("graph", flag.ExitOnError)
+	specFile := flags.String("spec", "", "spec file e.g. samplenet.yaml")
+	flags.Var(&overlays, "overlay", "spec file merged on top of the spec, can be repeated")
+	flags.Var(&overrides, "set", "spec value override addressed by YAML path e.g. orderer.batch.timeout=5s, can be repeated")
+	format := flags.String("format", "dot", "graph format: dot or mermaid")
+	output := flags.String("output", "", "file where the graph is written instead of the standard output")
+	flags.StringVar(&templatesPath, "templates", "", "template override directories, separated as in PATH")
+	flags.Parse(args)
+
+	if *specFile == "" {
+		fmt.Fprintln(os.Stderr, "spec file must be specified")
+		os.Exit(1)
+	}
+
+	templateName, found := graphTemplates[*format]
+	if !found {
+		fmt.Fprintf(os.Stderr, "Unsupported graph format '%s', expected dot or mermaid\n", *format)
+		os.Exit(1)
+	}
+
+	spec, err := netSpec.LoadFromFiles(*specFile, overlays, overrides)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading network spec file: %v\n", err)
+		os.Exit(1)
+	}
+	spec.SetDefaults()
+	if err := spec.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Network spec is NOT valid: %v\n", err)
+		os.Exit(1)
+	}
+
+	model := netModel.BuildNetModelFrom(spec)
+	if err := model.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Network spec is NOT valid: %v\n", err)
+		os.Exit(1)
+	}
+
+	var graph bytes.Buffer
+	if err := loadTemplate(templateName).Execute(&graph, newTemplateContext(spec, model)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error rendering graph: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(graph.Bytes())
+		return
+	}
+	if err := ioutil.WriteFile(*output, graph.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing graph: %v\n", err)
+		os.Exit(1)
+	}
+}
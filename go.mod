@@ -1,3 +1,8 @@
 module github.com/ibm-silvergate/netcomposer
 
-require gopkg.in/yaml.v2 v2.2.8
+go 1.16
+
+require (
+	gopkg.in/yaml.v2 v2.2.8
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,570 @@
+/*
+Copyright IBM Corp. 2017 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/ibm-silvergate/netcomposer/netSpec"
+	"github.com/ibm-silvergate/netcomposer/templates"
+	yaml "gopkg.in/yaml.v2"
+)
+
+//specTemplate is rendered by init with the answers of the wizard
+const specTemplate = "spec-template.yaml"
+
+//initAnswers are the settings of the spec file written by init
+type initAnswers struct {
+	APIVersion           string
+	Version              string
+	Network              string
+	Domain               string
+	Description          string
+	FabricVersion        string
+	CAVersion            string
+	ThirdpartyVersion    string
+	OrdererType          string
+	Consenters           int
+	KafkaBrokers         int
+	ZookeeperNodes       int
+	Organizations        int
+	PeersPerOrganization int
+	UsersPerOrganization int
+	DBProvider           string
+	TLSEnabled           bool
+	Channels             []string
+	ChaincodesPath       string
+	Chaincodes           []*initChaincode
+}
+
+//initChaincode is a chaincode found under chaincodesPath
+type initChaincode struct {
+	Name     string
+	Language string
+	Path     string
+	Version  string
+	Channels []string
+}
+
+var (
+	networkNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+	domainRegexp      = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)+$`)
+	channelNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9.-]*$`)
+)
+
+//initCommand writes a commented spec file from answers to a few questions, or from flags when not interactive
+func initCommand(args []string) {
+	answers := &initAnswers{APIVersion: netSpec.APIVersion, Version: version}
+	var channels, chaincodes string
+
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	output := flags.String("output", "", "spec file to write, <network>.yaml by default")
+	force := flags.Bool("force", false, "replace the spec file if it exists")
+	nonInteractive := flags.Bool("non-interactive", false, "take answers from flags without asking")
+	templateDirs := flags.String("templates", "", "template override directories, separated as in PATH")
+	flags.StringVar(&answers.Network, "network", "samplenet", "network name")
+	flags.StringVar(&answers.Domain, "domain", "", "domain of the organizations, <network>.com by default")
+	flags.StringVar(&answers.Description, "description", "a Fabric network bootstrapped with netcomposer", "network description")
+	flags.StringVar(&answers.FabricVersion, "fabric-version", "2.2.1", "version tag of Fabric images")
+	flags.StringVar(&answers.CAVersion, "ca-version", "", "version tag of the Fabric CA image, inferred from the Fabric version by default")
+	flags.StringVar(&answers.ThirdpartyVersion, "thirdparty-version", "", "version tag of CouchDB, Kafka and ZooKeeper images, inferred from the Fabric version by default")
+	flags.StringVar(&answers.OrdererType, "orderer", netSpec.OrderingServiceEtcdRaft, "orderer type: solo, kafka or etcdraft")
+	flags.IntVar(&answers.Consenters, "consenters", 3, "number of ordering nodes (kafka and etcdraft)")
+	flags.IntVar(&answers.KafkaBrokers, "kafka-brokers", 3, "number of Kafka brokers (kafka)")
+	flags.IntVar(&answers.ZookeeperNodes, "zookeeper-nodes", 3, "number of ZooKeeper nodes (kafka)")
+	flags.IntVar(&answers.Organizations, "orgs", 2, "number of peer organizations")
+	flags.IntVar(&answers.PeersPerOrganization, "peers", 2, "number of peers per organization")
+	flags.IntVar(&answers.UsersPerOrganization, "users", 1, "number of users per organization")
+	flags.StringVar(&answers.DBProvider, "db", netSpec.DBProviderGoLevelDB, "state database: goleveldb or CouchDB")
+	flags.BoolVar(&answers.TLSEnabled, "tls", true, "enable TLS")
+	flags.StringVar(&channels, "channels", "mychannel", "comma separated channel names")
+	flags.StringVar(&answers.ChaincodesPath, "chaincodes-path", "./sample-chaincodes/", "directory holding the chaincodes")
+	flags.StringVar(&chaincodes, "chaincodes", "all", "comma separated chaincode paths relative to the chaincodes path, all or none")
+	flags.Parse(args)
+
+	prompter := &prompter{interactive: !*nonInteractive, reader: bufio.NewReader(os.Stdin)}
+	answers.Channels = splitList(channels)
+
+	var content []byte
+	for {
+		err := answers.ask(prompter, chaincodes)
+		if err == nil {
+			content, err = answers.render(filepath.SplitList(*templateDirs))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error initializing network spec: %v\n", err)
+			os.Exit(1)
+		}
+
+		err = validateSpecContent(content)
+		if err == nil {
+			break
+		}
+		if !prompter.interactive {
+			fmt.Fprintf(os.Stderr, "Network spec is NOT valid: %v\n", err)
+			os.Exit(1)
+		}
+		//Questions are asked again with the previous answers as defaults
+		fmt.Printf("Network spec is NOT valid: %v\n\n", err)
+		chaincodes = answers.chaincodePaths()
+	}
+
+	target := *output
+	if target == "" {
+		target = answers.Network + ".yaml"
+	}
+	if _, err := os.Stat(target); err == nil && !*force {
+		fmt.Fprintf(os.Stderr, "%s already exists, use -force to replace it\n", target)
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile(target, content, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing network spec: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Network spec written to %s, generate the network with: netcomposer -spec %s\n", target, target)
+}
+
+//ask fills the answers, flag values are the defaults of the questions
+func (answers *initAnswers) ask(p *prompter, chaincodes string) error {
+	if err := p.ask("network", "Network name", &answers.Network, checkRegexp(networkNameRegexp)); err != nil {
+		return err
+	}
+	if answers.Domain == "" {
+		answers.Domain = answers.Network + ".com"
+	}
+	if err := p.ask("domain", "Domain", &answers.Domain, checkRegexp(domainRegexp)); err != nil {
+		return err
+	}
+	if err := p.ask("description", "Description", &answers.Description, nil); err != nil {
+		return err
+	}
+
+	if err := p.ask("fabric-version", "Fabric version", &answers.FabricVersion, checkFabricVersion); err != nil {
+		return err
+	}
+	fabricVersion, _ := netSpec.ParseFabricVersion(answers.FabricVersion)
+	if answers.CAVersion == "" {
+		answers.CAVersion = defaultCAVersion(fabricVersion, answers.FabricVersion)
+	}
+	if err := p.ask("ca-version", "Fabric CA version", &answers.CAVersion, checkFabricVersion); err != nil {
+		return err
+	}
+	if answers.ThirdpartyVersion == "" {
+		answers.ThirdpartyVersion = defaultThirdpartyVersion(fabricVersion)
+	}
+	if err := p.ask("thirdparty-version", "CouchDB, Kafka and ZooKeeper version", &answers.ThirdpartyVersion, checkFabricVersion); err != nil {
+		return err
+	}
+
+	ordererTypes := []string{netSpec.OrderingServiceSOLO, netSpec.OrderingServiceKafKa, netSpec.OrderingServiceEtcdRaft}
+	if err := p.ask("orderer", "Orderer type ("+strings.Join(ordererTypes, ", ")+")", &answers.OrdererType, checkChoice(ordererTypes)); err != nil {
+		return err
+	}
+	if answers.OrdererType != netSpec.OrderingServiceSOLO {
+		if err := p.askInt("consenters", "Ordering nodes", &answers.Consenters, 1); err != nil {
+			return err
+		}
+	}
+	if answers.OrdererType == netSpec.OrderingServiceKafKa {
+		if err := p.askInt("kafka-brokers", "Kafka brokers", &answers.KafkaBrokers, 1); err != nil {
+			return err
+		}
+		if err := p.askInt("zookeeper-nodes", "ZooKeeper nodes", &answers.ZookeeperNodes, 1); err != nil {
+			return err
+		}
+	}
+	if err := p.askBool("tls", "Enable TLS", &answers.TLSEnabled); err != nil {
+		return err
+	}
+
+	if err := p.askInt("orgs", "Peer organizations", &answers.Organizations, 1); err != nil {
+		return err
+	}
+	if err := p.askInt("peers", "Peers per organization", &answers.PeersPerOrganization, 1); err != nil {
+		return err
+	}
+	if err := p.askInt("users", "Users per organization", &answers.UsersPerOrganization, 1); err != nil {
+		return err
+	}
+
+	dbProviders := []string{netSpec.DBProviderGoLevelDB, netSpec.DBProviderCouchDB}
+	if err := p.ask("db", "State database ("+strings.Join(dbProviders, ", ")+")", &answers.DBProvider, checkChoice(dbProviders)); err != nil {
+		return err
+	}
+
+	if err := p.askList("channels", "Channels", &answers.Channels, checkRegexp(channelNameRegexp)); err != nil {
+		return err
+	}
+	if len(answers.Channels) == 0 {
+		answers.Chaincodes = nil
+		return nil
+	}
+
+	return answers.askChaincodes(p, chaincodes)
+}
+
+//askChaincodes selects among the chaincodes found under the chaincodes path and the channels they are deployed to
+func (answers *initAnswers) askChaincodes(p *prompter, chaincodes string) error {
+	if !p.interactive && strings.TrimSpace(chaincodes) == "none" {
+		answers.Chaincodes = nil
+		return nil
+	}
+
+	if err := p.ask("chaincodes-path", "Chaincodes path", &answers.ChaincodesPath, checkDirectory); err != nil {
+		return err
+	}
+
+	candidates, err := findChaincodes(answers.ChaincodesPath)
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		fmt.Printf("No chaincode found in %s\n", answers.ChaincodesPath)
+		answers.Chaincodes = nil
+		return nil
+	}
+
+	if p.interactive {
+		fmt.Printf("Chaincodes found in %s:\n", answers.ChaincodesPath)
+		for i, candidate := range candidates {
+			fmt.Printf("  %d) %s (%s)\n", i+1, candidate.Path, candidate.Language)
+		}
+	}
+	selection := func(value string) error {
+		_, err := selectChaincodes(candidates, value)
+		return err
+	}
+	if err := p.ask("chaincodes", "Chaincodes (numbers or paths, all or none)", &chaincodes, selection); err != nil {
+		return err
+	}
+	answers.Chaincodes, _ = selectChaincodes(candidates, chaincodes)
+
+	channels := make(map[string]bool, len(answers.Channels))
+	for _, channel := range answers.Channels {
+		channels[channel] = true
+	}
+	checkChannel := func(channel string) error {
+		if !channels[channel] {
+			return fmt.Errorf("unknown channel '%s'", channel)
+		}
+		return nil
+	}
+	for _, chaincode := range answers.Chaincodes {
+		chaincode.Channels = answers.Channels
+		if err := p.askList("chaincodes", "Channels of chaincode "+chaincode.Name, &chaincode.Channels, checkChannel); err != nil {
+			return err
+		}
+		if len(chaincode.Channels) == 0 {
+			return fmt.Errorf("Chaincode '%s' must be deployed to at least one channel", chaincode.Name)
+		}
+	}
+	return nil
+}
+
+//chaincodePaths returns the selected chaincodes, asked again as default
+func (answers *initAnswers) chaincodePaths() string {
+	if len(answers.Chaincodes) == 0 {
+		return "none"
+	}
+	paths := make([]string, len(answers.Chaincodes))
+	for i, chaincode := range answers.Chaincodes {
+		paths[i] = chaincode.Path
+	}
+	return strings.Join(paths, ",")
+}
+
+func (answers *initAnswers) render(overrideDirs []string) ([]byte, error) {
+	content, err := templates.Load(specTemplate, overrideDirs)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := template.New(specTemplate).Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	var spec bytes.Buffer
+	if err := t.Execute(&spec, answers); err != nil {
+		return nil, err
+	}
+	return spec.Bytes(), nil
+}
+
+//validateSpecContent applies the rules of the generator to a spec written by init
+func validateSpecContent(content []byte) error {
+	spec := &netSpec.NetSpec{}
+	if err := yaml.Unmarshal(content, spec); err != nil {
+		return fmt.Errorf("Error parsing network spec: %v", err)
+	}
+	spec.SetDefaults()
+	return spec.Validate()
+}
+
+//findChaincodes returns the directories under the chaincodes path holding a chaincode, by language
+func findChaincodes(chaincodesPath string) ([]*initChaincode, error) {
+	var chaincodes []*initChaincode
+	err := filepath.Walk(chaincodesPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != chaincodesPath && (strings.HasPrefix(info.Name(), ".") || info.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
+
+		language := chaincodeLanguage(path)
+		if language == "" {
+			return nil
+		}
+		relPath, err := filepath.Rel(chaincodesPath, path)
+		if err != nil {
+			return err
+		}
+		chaincodes = append(chaincodes, &initChaincode{
+			Name:     filepath.Base(path),
+			Language: language,
+			Path:     filepath.ToSlash(relPath),
+			Version:  "1.0",
+		})
+		//Nested directories belong to the chaincode
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error scanning chaincodes path: %v", err)
+	}
+	return chaincodes, nil
+}
+
+//chaincodeLanguage infers the language of the chaincode in a directory, empty when it holds none
+func chaincodeLanguage(dir string) string {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	switch {
+	case exists("package.json"):
+		return "node"
+	case exists("pom.xml"), exists("build.gradle"):
+		return "java"
+	}
+	if goFiles, _ := filepath.Glob(filepath.Join(dir, "*.go")); len(goFiles) > 0 {
+		return "golang"
+	}
+	return ""
+}
+
+//selectChaincodes returns the candidates selected by number or path, or all and none
+func selectChaincodes(candidates []*initChaincode, selection string) ([]*initChaincode, error) {
+	switch strings.TrimSpace(selection) {
+	case "all":
+		return candidates, nil
+	case "none", "":
+		return nil, nil
+	}
+
+	var selected []*initChaincode
+	for _, item := range splitList(selection) {
+		if n, err := strconv.Atoi(item); err == nil && n >= 1 && n <= len(candidates) {
+			selected = append(selected, candidates[n-1])
+			continue
+		}
+
+		found := false
+		for _, candidate := range candidates {
+			if candidate.Path == strings.Trim(filepath.ToSlash(item), "/") {
+				selected = append(selected, candidate)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no chaincode '%s'", item)
+		}
+	}
+	return selected, nil
+}
+
+//defaultCAVersion returns the Fabric CA release matching a Fabric release, the CA is versioned apart since Fabric 2.0
+func defaultCAVersion(fabricVersion *netSpec.FabricVersion, fabricTag string) string {
+	if fabricVersion != nil && fabricVersion.AtLeast(2, 0, 0) {
+		return "1.4.9"
+	}
+	return fabricTag
+}
+
+//defaultThirdpartyVersion returns the CouchDB, Kafka and ZooKeeper images release matching a Fabric release
+func defaultThirdpartyVersion(fabricVersion *netSpec.FabricVersion) string {
+	if fabricVersion != nil && fabricVersion.AtLeast(1, 4, 0) {
+		return "0.4.22"
+	}
+	return "0.4.13"
+}
+
+func checkRegexp(re *regexp.Regexp) func(string) error {
+	return func(value string) error {
+		if !re.MatchString(value) {
+			return fmt.Errorf("'%s' does not match %s", value, re)
+		}
+		return nil
+	}
+}
+
+func checkChoice(choices []string) func(string) error {
+	return func(value string) error {
+		for _, choice := range choices {
+			if value == choice {
+				return nil
+			}
+		}
+		return fmt.Errorf("'%s' is not one of %s", value, strings.Join(choices, ", "))
+	}
+}
+
+func checkFabricVersion(value string) error {
+	_, err := netSpec.ParseFabricVersion(value)
+	return err
+}
+
+func checkDirectory(value string) error {
+	info, err := os.Stat(value)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("'%s' is not a directory", value)
+	}
+	return nil
+}
+
+//splitList splits a comma separated list, ignoring blank items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+//prompter asks questions on the standard input, answers default to the flag values, which are only checked when not interactive
+type prompter struct {
+	interactive bool
+	reader      *bufio.Reader
+}
+
+//ask sets value to the answer to a question, asking again until check accepts it
+func (p *prompter) ask(flagName, question string, value *string, check func(string) error) error {
+	for {
+		answer := *value
+		if p.interactive {
+			fmt.Printf("%s [%s]: ", question, *value)
+			line, err := p.reader.ReadString('\n')
+			if err != nil && (err != io.EOF || line == "") {
+				fmt.Println()
+				return errors.New("No answer, end of input")
+			}
+			if line = strings.TrimSpace(line); line != "" {
+				answer = line
+			}
+		}
+
+		if check != nil {
+			if err := check(answer); err != nil {
+				if !p.interactive {
+					return fmt.Errorf("Invalid -%s: %v", flagName, err)
+				}
+				fmt.Printf("  %v\n", err)
+				continue
+			}
+		}
+		*value = answer
+		return nil
+	}
+}
+
+func (p *prompter) askInt(flagName, question string, value *int, min int) error {
+	answer := strconv.Itoa(*value)
+	err := p.ask(flagName, question, &answer, func(answer string) error {
+		n, err := strconv.Atoi(answer)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a number", answer)
+		}
+		if n < min {
+			return fmt.Errorf("%d is less than %d", n, min)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*value, _ = strconv.Atoi(answer)
+	return nil
+}
+
+func (p *prompter) askBool(flagName, question string, value *bool) error {
+	answer := "n"
+	if *value {
+		answer = "y"
+	}
+	err := p.ask(flagName, question+" (y/n)", &answer, func(answer string) error {
+		switch strings.ToLower(answer) {
+		case "y", "yes", "n", "no":
+			return nil
+		}
+		return errors.New("answer y or n")
+	})
+	if err != nil {
+		return err
+	}
+	*value = strings.HasPrefix(strings.ToLower(answer), "y")
+	return nil
+}
+
+//askList asks for a comma separated list, every item is checked
+func (p *prompter) askList(flagName, question string, value *[]string, check func(string) error) error {
+	answer := strings.Join(*value, ",")
+	err := p.ask(flagName, question+" (comma separated)", &answer, func(answer string) error {
+		for _, item := range splitList(answer) {
+			if err := check(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*value = splitList(answer)
+	return nil
+}
@@ -17,18 +17,29 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
-	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 
 	"github.com/ibm-silvergate/netcomposer/netModel"
 	"github.com/ibm-silvergate/netcomposer/netSpec"
+	"github.com/ibm-silvergate/netcomposer/templates"
+	yaml "gopkg.in/yaml.v2"
 )
 
 //Flags
@@ -36,8 +47,29 @@ var (
 	specFile      string
 	templatesPath string
 	outputPath    string
+	profiles      string
+	overlays      stringsFlag
+	overrides     stringsFlag
 )
 
+//stringsFlag collects the values of a flag that can be repeated
+type stringsFlag []string
+
+func (values *stringsFlag) String() string {
+	return strings.Join(*values, ",")
+}
+
+func (values *stringsFlag) Set(value string) error {
+	*values = append(*values, value)
+	return nil
+}
+
+//version is the netcomposer version, set at build time with -ldflags "-X main.version=<version>"
+var version = "0.1.0"
+
+//templateContext is the data of every template, see templates.Context
+var templateContext *templates.Context
+
 //Paths
 var (
 	networkPath       string
@@ -51,21 +83,33 @@ var (
 
 func readFlags() {
 	flag.StringVar(&specFile, "spec", "", "spec file e.g. samplenet.yaml")
-	flag.StringVar(&templatesPath, "templates", "templates", "templates path e.g. ./templates")
+	flag.StringVar(&templatesPath, "templates", "", "template override directories, separated as in PATH, e.g. ./team-templates:./templates")
 	flag.StringVar(&outputPath, "output", "out", "tools path e.g. $HOME/HF-networks")
+	flag.Var(&overlays, "overlay", "spec file merged on top of the spec, can be repeated")
+	flag.Var(&overrides, "set", "spec value override addressed by YAML path e.g. orderer.batch.timeout=5s, can be repeated")
+	flag.StringVar(&profiles, "profiles", "all", "connection profile variants: host, network or all")
 	flag.Parse()
 
 	if specFile == "" {
 		fmt.Fprintln(os.Stderr, "spec file must be specified")
 		os.Exit(1)
 	}
+
+	if profiles != "host" && profiles != "network" && profiles != "all" {
+		fmt.Fprintln(os.Stderr, "profiles must be one of host, network, all")
+		os.Exit(1)
+	}
 }
 
 func main() {
 
+	if len(os.Args) > 1 && runCommand(os.Args[1], os.Args[2:]) {
+		return
+	}
+
 	readFlags()
 
-	netSpec, err := netSpec.LoadFromFile(specFile)
+	netSpec, err := netSpec.LoadFromFiles(specFile, overlays, overrides)
 	if err != nil {
 		log.Fatalf("Error loading network spec file: %v", err)
 		os.Exit(1)
@@ -87,9 +131,13 @@ func main() {
 
 	createPaths(netModel)
 
+	templateContext = newTemplateContext(netSpec, netModel)
+
 	copyChaincodes(netSpec)
 
-	genCryptoConfigFile(netSpec)
+	genChaincodeIndexes(netModel)
+
+	genCryptoConfigFile()
 
 	genCryptoMaterial(netModel, "crypto-config.yaml")
 
@@ -97,17 +145,29 @@ func main() {
 
 	genDockerComposeFile(netModel)
 
+	genMonitoringConfig(netModel)
+
+	genExplorerConfig(netModel)
+
+	genCaliperConfig(netModel)
+
 	genNetworkConfigFile(netModel)
 
-	genNetworkConfigForOrgs(netModel)
+	genWallets(netModel)
 
-	genGenesisBlock(netModel, genesisPath, "genesis.block")
+	genConnectionProfiles(netModel)
 
-	genChannelConfig(netModel, channelsPath)
+	if netModel.ChannelParticipation {
+		genChannelGenesisBlocks(netModel, channelsPath)
+	} else {
+		genGenesisBlock(netModel, genesisPath, "genesis.block")
+
+		genChannelConfig(netModel, channelsPath)
+	}
 
-	genPullImagesScriptFile(netModel)
+	genArtifacts(netModel)
 
-	genProvisionScript(netModel)
+	genNetworkReadme(netModel)
 }
 
 func createPaths(netModel *netModel.NetModel) {
@@ -129,94 +189,371 @@ func createPaths(netModel *netModel.NetModel) {
 	os.MkdirAll(networkConfigPath, 0777)
 }
 
-func genCryptoConfigFile(spec *netSpec.NetSpec) {
+func genCryptoConfigFile() {
 	fmt.Print("Generating crypto config file: ")
 	cryptoConfigTemplate := loadTemplate("crypto-config-template.yaml")
-	panicOnError(execTemplate(cryptoConfigTemplate, spec, networkPath, "crypto-config.yaml"))
+	panicOnError(execTemplate(cryptoConfigTemplate, templateContext, networkPath, "crypto-config.yaml"))
 	fmt.Println("SUCCEED")
 }
 
 func genConfigTXFile(netModel *netModel.NetModel) {
 	fmt.Print("Generating configTX file: ")
 	configTXTemplate := loadTemplate("configtx-template.yaml")
-	panicOnError(execTemplate(configTXTemplate, netModel, networkPath, "configtx.yaml"))
+	panicOnError(execTemplate(configTXTemplate, templateContext, networkPath, "configtx.yaml"))
 	fmt.Println("SUCCEED")
 }
 
 func genDockerComposeFile(netModel *netModel.NetModel) {
 	fmt.Print("Generating docker compose file: ")
 	dockerComposeTemplate := loadTemplate("docker-compose-template.yaml")
-	panicOnError(execTemplate(dockerComposeTemplate, netModel, networkPath, "docker-compose.yaml"))
+	panicOnError(execTemplate(dockerComposeTemplate, templateContext, networkPath, "docker-compose.yaml"))
 	fmt.Println("SUCCEED")
 }
 
+//genMonitoringConfig generates the Prometheus scrape config and the Grafana datasource when those services are enabled
+func genMonitoringConfig(netModel *netModel.NetModel) {
+	if netModel.Observability == nil || netModel.Observability.Prometheus == nil {
+		return
+	}
+
+	fmt.Print("Generating Prometheus config file: ")
+	prometheusPath := filepath.Join(volumesPath, "prometheus")
+	os.MkdirAll(prometheusPath, 0777)
+	prometheusTemplate := loadTemplate("prometheus-template.yml")
+	panicOnError(execTemplate(prometheusTemplate, templateContext, prometheusPath, "prometheus.yml"))
+	fmt.Println("SUCCEED")
+
+	if netModel.Observability.Grafana == nil {
+		return
+	}
+
+	fmt.Print("Generating Grafana datasource file: ")
+	datasourcesPath := filepath.Join(volumesPath, "grafana", "provisioning", "datasources")
+	os.MkdirAll(datasourcesPath, 0777)
+	datasourceTemplate := loadTemplate("grafana-datasource-template.yaml")
+	panicOnError(execTemplate(datasourceTemplate, templateContext, datasourcesPath, "prometheus.yaml"))
+	fmt.Println("SUCCEED")
+}
+
+//genExplorerConfig generates Explorer config.json and the connection profile it uses
+func genExplorerConfig(netModel *netModel.NetModel) {
+	if netModel.Explorer == nil {
+		return
+	}
+
+	fmt.Print("Generating Explorer config files: ")
+	explorerPath := filepath.Join(volumesPath, "explorer")
+	profilePath := filepath.Join(explorerPath, "connection-profile")
+	os.MkdirAll(profilePath, 0777)
+
+	configTemplate := loadTemplate("explorer-config-template.yaml")
+	panicOnError(execTemplateJSON(configTemplate, templateContext, explorerPath, "config.json"))
+
+	profileTemplate := loadTemplate("explorer-profile-template.yaml")
+	panicOnError(execTemplateJSON(profileTemplate, templateContext, profilePath, netModel.Name+".json"))
+	fmt.Println("SUCCEED")
+}
+
+//newTemplateContext returns the context shared by every template
+func newTemplateContext(spec *netSpec.NetSpec, model *netModel.NetModel) *templates.Context {
+	ctx := &templates.Context{
+		NetModel: model,
+		Spec:     spec,
+		Paths: &templates.Paths{
+			Network:       networkPath,
+			Volumes:       volumesPath,
+			CryptoConfig:  cryptoConfigPath,
+			Chaincodes:    chaincodesPath,
+			Genesis:       genesisPath,
+			Channels:      channelsPath,
+			NetworkConfig: networkConfigPath,
+		},
+		Version: version,
+	}
+
+	for _, inNetwork := range profileVariants() {
+		if inNetwork {
+			ctx.NetworkProfiles = true
+		} else {
+			ctx.HostProfiles = true
+		}
+	}
+
+	return ctx
+}
+
+//profileVariants returns the connection profile variants selected by the profiles flag, host variant first
+func profileVariants() []bool {
+	switch profiles {
+	case "host":
+		return []bool{false}
+	case "network":
+		return []bool{true}
+	}
+	return []bool{false, true}
+}
+
+//profileFileName returns the file name of a connection profile variant, in-network variants are suffixed with -docker
+func profileFileName(name, ext string, inNetwork bool) string {
+	if inNetwork {
+		return name + "-docker" + ext
+	}
+	return name + ext
+}
+
+//genCaliperConfig generates the Caliper network config, the connection profiles it uses and a benchmark per chaincode
+func genCaliperConfig(netModel *netModel.NetModel) {
+	if netModel.Caliper == nil {
+		return
+	}
+
+	caliperPath := filepath.Join(networkPath, "caliper")
+	profilesPath := filepath.Join(caliperPath, "profiles")
+	benchmarksPath := filepath.Join(caliperPath, "benchmarks")
+	os.MkdirAll(profilesPath, 0777)
+	os.MkdirAll(benchmarksPath, 0777)
+
+	fmt.Print("Generating Caliper network config file: ")
+	networkTemplate := loadTemplate("caliper-network-template.yaml")
+	panicOnError(execTemplate(networkTemplate, templateContext, caliperPath, "networkconfig.yaml"))
+
+	profileTemplate := loadTemplate("caliper-profile-template.yaml")
+	for _, org := range netModel.PeerOrganizations {
+		panicOnError(execTemplate(profileTemplate, templateContext.WithOrganization(org), profilesPath, fmt.Sprintf("connection-%s.yaml", org.Name)))
+	}
+
+	workloadTemplate := loadTemplate("caliper-workload-template.js")
+	panicOnError(execTemplate(workloadTemplate, templateContext, caliperPath, "workload.js"))
+
+	scriptTemplate := loadTemplate("caliper-benchmark-template.sh")
+	panicOnError(execTemplate(scriptTemplate, templateContext, caliperPath, "benchmark.sh"))
+	panicOnError(os.Chmod(filepath.Join(caliperPath, "benchmark.sh"), 0755))
+	fmt.Println("SUCCEED")
+
+	benchmarkTemplate := loadTemplate("caliper-benchmark-template.yaml")
+	for _, cc := range netModel.Chaincodes {
+		if len(cc.Channels) == 0 {
+			continue
+		}
+
+		fmt.Printf("Generating Caliper benchmark for chaincode %s: ", cc.Name)
+		panicOnError(execTemplate(benchmarkTemplate, templateContext.WithChaincode(cc), benchmarksPath, fmt.Sprintf("%s.yaml", cc.Name)))
+		fmt.Println("SUCCEED")
+	}
+}
+
 func genNetworkConfigFile(netModel *netModel.NetModel) {
 	fmt.Print("Generating network config file: ")
 	networkConfigTemplate := loadTemplate("network-config-template.yaml")
-	panicOnError(execTemplate(networkConfigTemplate, netModel, networkConfigPath, "network-config.yaml"))
+	for _, inNetwork := range profileVariants() {
+		panicOnError(execTemplate(networkConfigTemplate, templateContext.WithVariant(inNetwork), networkConfigPath, profileFileName("network-config", ".yaml", inNetwork)))
+	}
 	fmt.Println("SUCCEED")
 }
 
-func genNetworkConfigForOrgs(netModel *netModel.NetModel) {
-	networkConfigTemplate := loadTemplate("network-config-org-template.yaml")
 
+//walletIdentity is a wallet entry in the format of the Fabric 2.x Node and Java SDKs and the Go SDK gateway
+type walletIdentity struct {
+	Credentials struct {
+		Certificate string `json:"certificate"`
+		PrivateKey  string `json:"privateKey"`
+	} `json:"credentials"`
+	MSPID   string `json:"mspId"`
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+}
+
+//legacyWalletIdentity is a wallet entry in the format of the Fabric 1.4 Node SDK, the user of a <user>/<user> file
+type legacyWalletIdentity struct {
+	Name             string   `json:"name"`
+	MSPID            string   `json:"mspid"`
+	Roles            []string `json:"roles"`
+	Affiliation      string   `json:"affiliation"`
+	EnrollmentSecret string   `json:"enrollmentSecret"`
+	Enrollment       struct {
+		SigningIdentity string `json:"signingIdentity"`
+		Identity        struct {
+			Certificate string `json:"certificate"`
+		} `json:"identity"`
+	} `json:"enrollment"`
+}
+
+//genWallets writes the identities of every organization as filesystem wallet entries named <user>.id, and in the
+//Fabric 1.4 layout: a <user> directory holding the <user> file and the <ski>-priv and <ski>-pub keys
+func genWallets(netModel *netModel.NetModel) {
 	for _, org := range netModel.PeerOrganizations {
-		fmt.Printf("Generating network config for organization %s: ", org.Name)
-		netClientDef := struct {
-			Network      string
-			Description  string
-			Organization string
-		}{
-			Network:      netModel.Name,
-			Description:  netModel.Description,
-			Organization: org.Name,
+		fmt.Printf("Generating wallet for organization %s: ", org.Name)
+		walletPath := filepath.Join(networkConfigPath, "wallets", org.Name)
+		panicOnError(os.MkdirAll(walletPath, 0777))
+
+		for _, user := range org.Users {
+			mspPath := filepath.Join(cryptoConfigPath, org.CryptoPath, "users", fmt.Sprintf("%s@%s", user, org.FullName), "msp")
+
+			certificate, err := ioutil.ReadFile(filepath.Join(mspPath, "signcerts", fmt.Sprintf("%s@%s-cert.pem", user, org.FullName)))
+			panicOnError(err)
+			privateKey, err := ioutil.ReadFile(filepath.Join(mspPath, "keystore", "secret.key"))
+			panicOnError(err)
+
+			identity := &walletIdentity{MSPID: org.Name + "MSP", Type: "X.509", Version: 1}
+			identity.Credentials.Certificate = string(certificate)
+			identity.Credentials.PrivateKey = string(privateKey)
+
+			content, err := json.MarshalIndent(identity, "", "  ")
+			panicOnError(err)
+			panicOnError(ioutil.WriteFile(filepath.Join(walletPath, user+".id"), content, 0600))
+
+			ski, publicKey, err := publicKeyOf(privateKey)
+			panicOnError(err)
+
+			legacyIdentity := &legacyWalletIdentity{Name: user, MSPID: org.Name + "MSP"}
+			legacyIdentity.Enrollment.SigningIdentity = ski
+			legacyIdentity.Enrollment.Identity.Certificate = string(certificate)
+
+			content, err = json.Marshal(legacyIdentity)
+			panicOnError(err)
+			userPath := filepath.Join(walletPath, user)
+			panicOnError(os.MkdirAll(userPath, 0777))
+			panicOnError(ioutil.WriteFile(filepath.Join(userPath, user), content, 0600))
+			panicOnError(ioutil.WriteFile(filepath.Join(userPath, ski+"-priv"), privateKey, 0600))
+			panicOnError(ioutil.WriteFile(filepath.Join(userPath, ski+"-pub"), publicKey, 0600))
 		}
 
-		panicOnError(
-			execTemplate(
-				networkConfigTemplate,
-				netClientDef,
-				networkConfigPath,
-				fmt.Sprintf("network-config-%s.yaml", org.Name)))
 		fmt.Println("SUCCEED")
 	}
 }
 
-func genPullImagesScriptFile(netModel *netModel.NetModel) {
-	fmt.Print("Generating script to pull fabric docker images: ")
-	pullImagesTemplate := loadTemplate("pull-docker-images-template.sh")
-	panicOnError(execTemplate(pullImagesTemplate, netModel, networkPath, "pull-docker-images.sh"))
+//publicKeyOf returns the subject key identifier of an ECDSA private key, the SHA-256 of its public point as computed by
+//the Fabric SDKs, and its public key in PEM format
+func publicKeyOf(privateKeyPEM []byte) (string, []byte, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return "", nil, fmt.Errorf("Private key is not PEM encoded")
+	}
 
-	args := []string{"+x", filepath.Join(filepath.Clean(networkPath), "pull-docker-images.sh")}
-	
-	cmd := exec.Command("chmod", args...)
-	if combinedOutput, err := cmd.CombinedOutput(); err != nil {
-		fmt.Printf("Err: %s\n", err)
-		fmt.Printf("\tCombined Output: %s\n", combinedOutput)
-		os.Exit(1)
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", nil, fmt.Errorf("Error parsing private key: %v", err)
 	}
-	fmt.Println("SUCCEED")
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return "", nil, fmt.Errorf("Private key is not an ECDSA key")
+	}
+
+	hash := sha256.Sum256(elliptic.Marshal(ecdsaKey.Curve, ecdsaKey.X, ecdsaKey.Y))
+	publicKey, err := x509.MarshalPKIXPublicKey(&ecdsaKey.PublicKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return hex.EncodeToString(hash[:]), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}), nil
 }
 
-func genProvisionScript(netModel *netModel.NetModel) {
-	fmt.Print("Generating provisioning script: ")
+//generatorContexts returns the contexts a generator renders, one per entity it iterates over
+func generatorContexts(netModel *netModel.NetModel, forEach string) []*templates.Context {
+	contexts := make([]*templates.Context, 0)
+	switch forEach {
+	case templates.ForEachOrg:
+		for _, org := range netModel.PeerOrganizations {
+			contexts = append(contexts, templateContext.WithOrganization(org))
+		}
+	case templates.ForEachPeer:
+		for _, peer := range netModel.Peers {
+			contexts = append(contexts, templateContext.WithPeer(peer))
+		}
+	case templates.ForEachChannel:
+		names := make([]string, 0, len(netModel.Channels))
+		for name := range netModel.Channels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			contexts = append(contexts, templateContext.WithChannel(netModel.Channels[name]))
+		}
+	case templates.ForEachChaincode:
+		for _, chaincode := range netModel.Chaincodes {
+			contexts = append(contexts, templateContext.WithChaincode(chaincode))
+		}
+	default:
+		contexts = append(contexts, templateContext)
+	}
+	return contexts
+}
 
-	provisionTemplate := loadTemplate("provision-template.sh")
-	panicOnError(execTemplate(provisionTemplate, netModel, networkPath, "provision.sh"))
+//genArtifacts renders the artifacts listed in the generators manifest
+func genArtifacts(netModel *netModel.NetModel) {
+	generators, err := templates.LoadGenerators(filepath.SplitList(templatesPath))
+	if err != nil {
+		log.Fatalln(err)
+	}
 
-	args := []string{"+x", filepath.Join(filepath.Clean(networkPath), "provision.sh")}
+	for _, generator := range generators {
+		fmt.Printf("Generating %s: ", generator.Name)
+		artifactTemplate := loadTemplate(generator.Template)
+		targetTemplate, err := template.New(generator.Name).Funcs(templates.Funcs(networkPath, cryptoConfigPath)).Parse(generator.Target)
+		if err != nil {
+			log.Fatalf("Invalid target of generator '%s': %v", generator.Name, err)
+		}
 
-	cmd := exec.Command("chmod", args...)
-	
-	if combinedOutput, err := cmd.CombinedOutput(); err != nil {
-		fmt.Printf("Err: %s\n", err)
-		fmt.Printf("\tCombined Output: %s\n", combinedOutput)
-		os.Exit(1)
+		for _, ctx := range generatorContexts(netModel, generator.ForEach) {
+			var target bytes.Buffer
+			panicOnError(targetTemplate.Execute(&target, ctx))
+
+			targetFile := filepath.Clean(target.String())
+			if filepath.IsAbs(targetFile) || targetFile == ".." || strings.HasPrefix(targetFile, ".."+string(filepath.Separator)) {
+				log.Fatalf("Target '%s' of generator '%s' is outside the network directory", targetFile, generator.Name)
+			}
+
+			targetPath := filepath.Join(networkPath, filepath.Dir(targetFile))
+			panicOnError(os.MkdirAll(targetPath, 0777))
+			panicOnError(execTemplate(artifactTemplate, ctx, targetPath, filepath.Base(targetFile)))
+			panicOnError(os.Chmod(filepath.Join(targetPath, filepath.Base(targetFile)), generator.FileMode))
+		}
+		fmt.Println("SUCCEED")
 	}
+}
 
+//genNetworkReadme documents the generated connection profiles, identities and scripts of the network
+func genNetworkReadme(netModel *netModel.NetModel) {
+	fmt.Print("Generating network README: ")
+	readmeTemplate := loadTemplate("network-readme-template.md")
+
+	panicOnError(execTemplate(readmeTemplate, templateContext, networkPath, "README.md"))
 	fmt.Println("SUCCEED")
 }
 
+//genConnectionProfiles generates the YAML and JSON connection profiles and the Fabric Gateway settings of every organization
+func genConnectionProfiles(netModel *netModel.NetModel) {
+	profileTemplate := loadTemplate("connection-profile-template.yaml")
+	gatewayTemplate := loadTemplate("gateway-template.env")
+
+	for _, org := range netModel.PeerOrganizations {
+		fmt.Printf("Generating connection profiles for organization %s: ", org.Name)
+		gatewayPath := filepath.Join(networkConfigPath, "gateway", org.Name)
+		panicOnError(os.MkdirAll(gatewayPath, 0777))
+
+		for _, inNetwork := range profileVariants() {
+			profileCtx := templateContext.WithOrganization(org).WithVariant(inNetwork)
+			profileName := "connection-" + org.Name
+
+			panicOnError(execTemplate(profileTemplate, profileCtx, networkConfigPath, profileFileName(profileName, ".yaml", inNetwork)))
+			panicOnError(execTemplateJSON(profileTemplate, profileCtx, networkConfigPath, profileFileName(profileName, ".json", inNetwork)))
+
+			//Gateway clients of the organization connect to its first peer with the identity of User1
+			panicOnError(execTemplate(gatewayTemplate, profileCtx, gatewayPath, profileFileName("gateway", ".env", inNetwork)))
+		}
+
+		userPath := filepath.Join(cryptoConfigPath, org.CryptoPath, "users", "User1@"+org.FullName)
+		panicOnError(copyFile(filepath.Join(userPath, "msp", "signcerts", fmt.Sprintf("User1@%s-cert.pem", org.FullName)), filepath.Join(gatewayPath, "cert.pem")))
+		panicOnError(copyFile(filepath.Join(userPath, "msp", "keystore", "secret.key"), filepath.Join(gatewayPath, "key.pem")))
+		if netModel.TLSEnabled {
+			panicOnError(copyFile(filepath.Join(userPath, "tls", "ca.crt"), filepath.Join(gatewayPath, "tls-ca.pem")))
+		}
+
+		fmt.Println("SUCCEED")
+	}
+}
+
+
+
 func fixSKFilename(path string, f os.FileInfo, err error) (e error) {
 	if strings.HasSuffix(f.Name(), "_sk") {
 		dir := filepath.Dir(path)
@@ -296,6 +633,32 @@ func genChannelConfig(netModel *netModel.NetModel, channelsPath string) {
 	}
 }
 
+//genChannelGenesisBlocks generates the blocks orderers and peers join when there is no system channel
+func genChannelGenesisBlocks(netModel *netModel.NetModel, channelsPath string) {
+	for _, ch := range netModel.Channels {
+		fmt.Printf("Generating genesis block for channel %s: ", ch.Name)
+
+		args := []string{
+			"-profile", ch.Name,
+			"-outputBlock", filepath.Join(channelsPath, fmt.Sprintf("%s.block", ch.Name)),
+			"-channelID", ch.Name,
+		}
+
+		cmd := exec.Command(fmt.Sprintf("configtxgen"), args...)
+
+		netPath, _ := filepath.Abs(networkPath)
+		cmd.Env = append(cmd.Env, fmt.Sprintf("FABRIC_CFG_PATH=%s", netPath))
+
+		if combinedOutput, err := cmd.CombinedOutput(); err != nil {
+			fmt.Printf("Err: %s\n", err)
+			fmt.Printf("\tCombined Output: %s\n", combinedOutput)
+			os.Exit(1)
+		}
+
+		fmt.Println("SUCCEED")
+	}
+}
+
 func copyChaincodes(spec *netSpec.NetSpec) {
 	if spec.ChaincodesPath != "" {
 		fmt.Printf("Copying chaincodes to %s: ", chaincodesPath)
@@ -306,6 +669,42 @@ func copyChaincodes(spec *netSpec.NetSpec) {
 	}
 }
 
+func genChaincodeIndexes(netModel *netModel.NetModel) {
+	if netModel.DBProvider != netSpec.DBProviderCouchDB {
+		return
+	}
+
+	for _, cc := range netModel.Chaincodes {
+		if len(cc.Indexes) == 0 {
+			continue
+		}
+
+		fmt.Printf("Generating CouchDB indexes for chaincode %s: ", cc.Name)
+
+		/* Fabric deploys the indexes found under META-INF/statedb/couchdb/indexes
+		 * of the chaincode directory when the chaincode is installed
+		 */
+		indexesPath := filepath.Join(chaincodesPath, cc.Path, "META-INF", "statedb", "couchdb", "indexes")
+		panicOnError(os.MkdirAll(indexesPath, 0777))
+
+		for _, idx := range cc.Indexes {
+			index := map[string]interface{}{
+				"index": map[string]interface{}{"fields": idx.Fields},
+				"ddoc":  idx.DesignDoc,
+				"name":  idx.Name,
+				"type":  "json",
+			}
+
+			content, err := json.MarshalIndent(index, "", "  ")
+			panicOnError(err)
+
+			panicOnError(ioutil.WriteFile(filepath.Join(indexesPath, idx.Name+".json"), content, 0644))
+		}
+
+		fmt.Println("SUCCEED")
+	}
+}
+
 func copyFolder(sPath, dPath string) {
 	sourcePath := os.ExpandEnv(sPath)
 	_, err := os.Stat(sourcePath)
@@ -318,7 +717,8 @@ func copyFolder(sPath, dPath string) {
 		os.Exit(1)
 	}
 
-	cpArgs := []string{"-r", sourcePath, destinationPath}
+	// Copy folder contents so chaincode paths are relative to the destination folder
+	cpArgs := []string{"-r", filepath.Clean(sourcePath) + string(filepath.Separator) + ".", destinationPath}
 
 	cmd := exec.Command("cp", cpArgs...)
 	if combinedOutput, err := cmd.CombinedOutput(); err != nil {
@@ -328,36 +728,28 @@ func copyFolder(sPath, dPath string) {
 	}
 }
 
-func loadTemplate(templateFile string) *template.Template {
-	templateFilePath := path.Join(templatesPath, templateFile)
+func copyFile(source, destination string) error {
+	content, err := ioutil.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(destination, content, 0600)
+}
 
-	fm := template.FuncMap{
-		"Sequence": sequence,
-		"ToLower":  strings.ToLower,
-		"Inc":      inc,
+//loadTemplate parses a template, files in the override directories replace the stock templates compiled into the binary
+func loadTemplate(templateFile string) *template.Template {
+	content, err := templates.Load(templateFile, filepath.SplitList(templatesPath))
+	if err != nil {
+		log.Fatalln(err)
 	}
 
-	t, err := template.New(templateFile).Funcs(fm).ParseFiles(templateFilePath)
+	t, err := template.New(templateFile).Funcs(templates.Funcs(networkPath, cryptoConfigPath)).Parse(string(content))
 	if err != nil {
 		log.Fatalln(err)
 	}
 	return t
 }
 
-func sequence(start, end int) (stream chan int) {
-	stream = make(chan int)
-	go func() {
-		for i := start; i <= end; i++ {
-			stream <- i
-		}
-		close(stream)
-	}()
-	return
-}
-
-func inc(val int) int {
-	return val + 1
-}
 
 func execTemplate(t *template.Template, model interface{}, targetPath string, targetFile string) error {
 	path := filepath.Join(targetPath, targetFile)
@@ -377,6 +769,47 @@ func execTemplate(t *template.Template, model interface{}, targetPath string, ta
 	return nil
 }
 
+//execTemplateJSON executes a YAML template and writes the result as JSON
+func execTemplateJSON(t *template.Template, model interface{}, targetPath string, targetFile string) error {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, model); err != nil {
+		log.Println("Error executing template: ", err)
+		return err
+	}
+
+	var content interface{}
+	if err := yaml.Unmarshal(buf.Bytes(), &content); err != nil {
+		log.Println("Error parsing template output: ", err)
+		return err
+	}
+
+	data, err := json.MarshalIndent(jsonCompatible(content), "", "  ")
+	if err != nil {
+		log.Println("Error converting template output to JSON: ", err)
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(targetPath, targetFile), data, 0644)
+}
+
+//jsonCompatible converts the maps decoded by yaml, keyed by interface{}, to maps keyed by string
+func jsonCompatible(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[fmt.Sprint(key)] = jsonCompatible(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range v {
+			v[i] = jsonCompatible(val)
+		}
+		return v
+	}
+	return value
+}
+
 func panicOnError(err error) {
 	if err != nil {
 		panic(err)
@@ -10,34 +10,84 @@ type NetModel struct {
 	FabricVersionTag     string
 	CaVersionTag         string
 	ThirdpartyVersionTag string
+	ExplorerVersionTag   string
 	ChannelCreationDelay int
 	Name                 string
 	Domain               string
 	Description          string
 	OrdererType          string
+	ChannelParticipation bool
+	Batch                *Batch
 	KafkaBrokers         []*KafkaBroker
 	ZooKeeperNodes       []*ZKNode
 	DBProvider           string
 	OrdererOrganization  *Organization
+	OrdererOrganizations []*Organization
 	Orderers             []*Orderer
 	CAs                  []*CA
 	PeerOrganizations    []*Organization
+	Consortiums          []*Consortium
 	Channels             map[string]*Channel
 	Peers                []*Peer
 	Chaincodes           []*Chaincode
 	LogLevel             string
+	LoggingSpec          string
+	FabricLoggingSpec    bool
 	TLSEnabled           bool
+	ChannelPolicies      map[string]*Policy
+	ApplicationPolicies  map[string]*Policy
+	Capabilities         *Capabilities
+	Observability        *Observability
+	Explorer             *Explorer
+	Caliper              *Caliper
+	EmbedPEMs            bool
 }
 
 type Organization struct {
 	Name     string
 	FullName string
-	Peers    []*Peer
+	//Domain and CryptoPath locate the organization material generated by cryptogen
+	Domain     string
+	CryptoPath string
+	Peers      []*Peer
+	Policies   map[string]*Policy
+	//Users are the names of the identities generated for the organization, e.g. Admin, User1
+	Users []string
+}
+
+//Capabilities holds the capability enabled at each level, empty when configtxgen defaults apply
+type Capabilities struct {
+	Channel     string
+	Orderer     string
+	Application string
+}
+
+type Policy struct {
+	Type string
+	Rule string
+}
+
+type Consortium struct {
+	Name          string
+	Organizations []*Organization
 }
 
 type Channel struct {
 	Name          string
+	Consortium    *Consortium
 	Organizations []*ChannelOrg
+	Batch         *Batch
+	BatchOverride bool
+	ACLs          map[string]string
+	Chaincodes    []*Chaincode
+}
+
+//Batch holds the block cutting parameters, sizes are expressed in bytes
+type Batch struct {
+	Timeout           string
+	MaxMessageCount   int
+	AbsoluteMaxBytes  uint32
+	PreferredMaxBytes uint32
 }
 
 type ChannelOrg struct {
@@ -54,20 +104,26 @@ type ChannelPeer struct {
 }
 
 type CA struct {
-	Name        string
-	FullName    string
-	OrgFullName string
-	ExposedPort int
-	Port        int
-}
-
-type Orderer struct {
 	Name         string
+	FullName     string
+	OrgFullName  string
 	Organization *Organization
 	ExposedPort  int
 	Port         int
 }
 
+type Orderer struct {
+	Name                  string
+	Organization          *Organization
+	ExposedPort           int
+	Port                  int
+	ExposedAdminPort      int
+	AdminPort             int
+	ExposedOperationsPort int
+	OperationsPort        int
+	LoggingSpec           string
+}
+
 type Peer struct {
 	Name                string
 	Organization        *Organization
@@ -76,7 +132,45 @@ type Peer struct {
 	Port                int
 	ExposedEventPort    int
 	EventPort           int
-	DB                  *PeerDB
+	//Operations ports are only set when observability is enabled
+	ExposedOperationsPort int
+	OperationsPort        int
+	LoggingSpec           string
+	DB                    *PeerDB
+}
+
+//Observability holds the metrics settings of every node and the monitoring services to start
+type Observability struct {
+	MetricsProvider string
+	StatsdAddress   string
+	Prometheus      *MonitoringService
+	Grafana         *MonitoringService
+}
+
+//Explorer is the Hyperledger Explorer instance, connected to the network as the admin of Organization
+type Explorer struct {
+	Name          string
+	DBName        string
+	Organization  *Organization
+	ExposedPort   int
+	Port          int
+	AdminUsername string
+	AdminPassword string
+	DBUsername    string
+	DBPassword    string
+}
+
+//Caliper holds the settings of the generated Caliper benchmarks
+type Caliper struct {
+	//SUT is the Fabric SDK version Caliper binds to, e.g. fabric:2.2
+	SUT string
+}
+
+type MonitoringService struct {
+	Name        string
+	Image       string
+	ExposedPort int
+	Port        int
 }
 
 type PeerDB struct {
@@ -98,9 +192,28 @@ type Chaincode struct {
 	Language       string
 	Path           string
 	Version        string
+	Indexes        []*ChaincodeIndex
+	Benchmark      *ChaincodeBenchmark
 	EndorcingRules []*EndorcingRule
 }
 
+//ChaincodeBenchmark is the Caliper round generated for a chaincode
+type ChaincodeBenchmark struct {
+	Function string
+	Args     []string
+	ReadOnly bool
+	Workers  int
+	TxNumber int
+	TPS      int
+	InitArgs []string
+}
+
+type ChaincodeIndex struct {
+	Name      string
+	DesignDoc string
+	Fields    []string
+}
+
 type EndorcingRule struct {
 	Terms []*EndorcingRuleTerm
 }
@@ -121,37 +234,58 @@ type ZKNode struct {
 }
 
 func BuildNetModelFrom(spec *netSpec.NetSpec) *NetModel {
-	ordererOrganization := &Organization{
-		Name:     "ordererOrg",
-		FullName: fmt.Sprintf("ordererOrg.%s", spec.Domain),
-	}
+	ordererOrganizationList := make([]*Organization, len(spec.Orderer.Organizations))
+	ordererList := make([]*Orderer, 0, spec.Orderer.Consenters)
+	for i, ordOrgSpec := range spec.Orderer.Organizations {
+		ordererOrganizationList[i] = &Organization{
+			Name:       ordOrgSpec.Name,
+			FullName:   fmt.Sprintf("%s.%s", ordOrgSpec.Name, ordOrgSpec.Domain),
+			Domain:     ordOrgSpec.Domain,
+			CryptoPath: fmt.Sprintf("ordererOrganizations/%s", ordOrgSpec.Domain),
+			Policies:   buildPolicies(spec.Policies.Organizations[ordOrgSpec.Name]),
+		}
 
-	ordererList := make([]*Orderer, spec.Orderer.Consenters)
-	for i := 0; i < spec.Orderer.Consenters; i++ {
-		ordererList[i] = &Orderer{
-			Name:         fmt.Sprintf("orderer%d.%s", i+1, spec.Domain),
-			Organization: ordererOrganization,
-			ExposedPort:  7050 + 100*i,
-			Port:         7050,
+		for j := 0; j < ordOrgSpec.Consenters; j++ {
+			ordererList = append(ordererList, &Orderer{
+				Name:             fmt.Sprintf("orderer%d.%s", j+1, ordOrgSpec.Domain),
+				Organization:     ordererOrganizationList[i],
+				ExposedPort:      7050 + 100*len(ordererList),
+				Port:             7050,
+				ExposedAdminPort: 7055 + 100*len(ordererList),
+				AdminPort:        7053,
+				LoggingSpec:      spec.Logging.NodeLoggingSpec(ordOrgSpec.Name, fmt.Sprintf("orderer%d.%s", j+1, ordOrgSpec.Domain)),
+			})
 		}
 	}
+	//Peers and clients are bound to the first orderer organization
+	ordererOrganization := ordererOrganizationList[0]
 
 	peerOrganizationList := make([]*Organization, spec.PeerOrgs)
 	caList := make([]*CA, spec.PeerOrgs)
 	peerList := make([]*Peer, spec.PeerOrgs*spec.PeersPerOrg)
 
 	for i := 0; i < spec.PeerOrgs; i++ {
+		orgName := netSpec.PeerOrgName(i + 1)
+		orgFullName := fmt.Sprintf("%s.%s", orgName, spec.Domain)
 		peerOrganizationList[i] = &Organization{
-			Name:     fmt.Sprintf("org%d", i+1),
-			FullName: fmt.Sprintf("org%d.%s", i+1, spec.Domain),
-			Peers:    make([]*Peer, spec.PeersPerOrg),
+			Name:       orgName,
+			FullName:   orgFullName,
+			Domain:     orgFullName,
+			CryptoPath: fmt.Sprintf("peerOrganizations/%s", orgFullName),
+			Peers:      make([]*Peer, spec.PeersPerOrg),
+			Policies:   buildPolicies(spec.Policies.Organizations[orgName]),
+			Users:      []string{"Admin"},
+		}
+		for u := 1; u <= spec.PeerOrgUsers; u++ {
+			peerOrganizationList[i].Users = append(peerOrganizationList[i].Users, fmt.Sprintf("User%d", u))
 		}
 
 		caList[i] = &CA{
-			Name:        fmt.Sprintf("ca.%s", peerOrganizationList[i].FullName),
-			OrgFullName: peerOrganizationList[i].FullName,
-			ExposedPort: 7054 + 100*i,
-			Port:        7054,
+			Name:         fmt.Sprintf("ca.%s", peerOrganizationList[i].FullName),
+			OrgFullName:  peerOrganizationList[i].FullName,
+			Organization: peerOrganizationList[i],
+			ExposedPort:  7054 + 100*i,
+			Port:         7054,
 		}
 
 		for j := 0; j < spec.PeersPerOrg; j++ {
@@ -182,6 +316,7 @@ func BuildNetModelFrom(spec *netSpec.NetSpec) *NetModel {
 				Port:                7051,
 				ExposedEventPort:    eventHostPort,
 				EventPort:           7053,
+				LoggingSpec:         spec.Logging.NodeLoggingSpec(orgName, fmt.Sprintf("peer%d.%s", j+1, peerOrganizationList[i].FullName)),
 				DB:                  peerdb,
 			}
 
@@ -190,6 +325,21 @@ func BuildNetModelFrom(spec *netSpec.NetSpec) *NetModel {
 		}
 	}
 
+	//Orderer organizations CAs are only started when requested
+	for i, ordOrgSpec := range spec.Orderer.Organizations {
+		if !ordOrgSpec.CA {
+			continue
+		}
+
+		caList = append(caList, &CA{
+			Name:         fmt.Sprintf("ca.%s", ordOrgSpec.Domain),
+			OrgFullName:  ordOrgSpec.Domain,
+			Organization: ordererOrganizationList[i],
+			ExposedPort:  7054 + 100*len(caList),
+			Port:         7054,
+		})
+	}
+
 	kafkaBrokerList := make([]*KafkaBroker, spec.Orderer.KafkaBrokers)
 	for i := 0; i < spec.Orderer.KafkaBrokers; i++ {
 		kafkaBrokerList[i] = &KafkaBroker{
@@ -206,6 +356,21 @@ func BuildNetModelFrom(spec *netSpec.NetSpec) *NetModel {
 		}
 	}
 
+	consortiumList := make([]*Consortium, len(spec.Consortiums))
+	consortiums := make(map[string]*Consortium, len(spec.Consortiums))
+	for i, consortiumSpec := range spec.Consortiums {
+		consortiumList[i] = &Consortium{
+			Name:          consortiumSpec.Name,
+			Organizations: make([]*Organization, len(consortiumSpec.Organizations)),
+		}
+		for j, orgID := range consortiumSpec.Organizations {
+			consortiumList[i].Organizations[j] = peerOrganizationList[orgID-1]
+		}
+		consortiums[consortiumSpec.Name] = consortiumList[i]
+	}
+
+	batch := buildBatch(spec.Orderer.Batch)
+
 	channels := make(map[string]*Channel, len(spec.Channels))
 	for _, chSpec := range spec.Channels {
 		chOrgList := make([]*ChannelOrg, len(chSpec.Organizations))
@@ -229,7 +394,47 @@ func BuildNetModelFrom(spec *netSpec.NetSpec) *NetModel {
 			}
 		}
 
-		channels[chSpec.Name] = &Channel{Name: chSpec.Name, Organizations: chOrgList}
+		channel := &Channel{
+			Name:          chSpec.Name,
+			Consortium:    consortiums[chSpec.Consortium],
+			Organizations: chOrgList,
+			Batch:         batch,
+		}
+
+		//Channel ACLs take precedence over the ones specified for every channel
+		channel.ACLs = make(map[string]string, len(spec.ACLs)+len(chSpec.ACLs))
+		for resource, policy := range spec.ACLs {
+			channel.ACLs[resource] = policy
+		}
+		for resource, policy := range chSpec.ACLs {
+			channel.ACLs[resource] = policy
+		}
+		if chSpec.Batch != nil {
+			channel.Batch = buildBatch(chSpec.Batch)
+			channel.BatchOverride = *channel.Batch != *batch
+		}
+
+		channels[chSpec.Name] = channel
+	}
+
+	var observability *Observability
+	if spec.Observability != nil {
+		observability = buildObservability(spec.Observability, ordererList, peerList, spec.Domain)
+	}
+
+	var explorer *Explorer
+	if spec.Explorer {
+		explorer = &Explorer{
+			Name:          fmt.Sprintf("explorer.%s", spec.Domain),
+			DBName:        fmt.Sprintf("explorerdb.%s", spec.Domain),
+			Organization:  explorerOrganization(peerOrganizationList, spec.Channels),
+			ExposedPort:   8080,
+			Port:          8080,
+			AdminUsername: spec.ExplorerCredentials.AdminUsername,
+			AdminPassword: spec.ExplorerCredentials.AdminPassword,
+			DBUsername:    spec.ExplorerCredentials.DBUsername,
+			DBPassword:    spec.ExplorerCredentials.DBPassword,
+		}
 	}
 
 	//Build chaincode list solving references (i.e. channels are referenced by name in spec model)
@@ -241,10 +446,30 @@ func BuildNetModelFrom(spec *netSpec.NetSpec) *NetModel {
 			Language: ccSpec.Language,
 			Version:  ccSpec.Version,
 			Path:     ccSpec.Path,
+			Indexes:  make([]*ChaincodeIndex, len(ccSpec.Indexes)),
+		}
+		for j, idxSpec := range ccSpec.Indexes {
+			cc.Indexes[j] = &ChaincodeIndex{
+				Name:      idxSpec.Name,
+				DesignDoc: idxSpec.DesignDoc,
+				Fields:    idxSpec.Fields,
+			}
+		}
+		if ccSpec.Benchmark != nil {
+			cc.Benchmark = &ChaincodeBenchmark{
+				Function: ccSpec.Benchmark.Function,
+				Args:     ccSpec.Benchmark.Args,
+				ReadOnly: ccSpec.Benchmark.ReadOnly,
+				Workers:  ccSpec.Benchmark.Workers,
+				TxNumber: ccSpec.Benchmark.TxNumber,
+				TPS:      ccSpec.Benchmark.TPS,
+				InitArgs: ccSpec.Benchmark.InitArgs,
+			}
 		}
 		//Resolve channel reference by name
 		for j, chName := range ccSpec.Channels {
 			cc.Channels[j] = channels[chName]
+			cc.Channels[j].Chaincodes = append(cc.Channels[j].Chaincodes, cc)
 		}
 		chaincodeList[i] = cc
 	}
@@ -254,23 +479,138 @@ func BuildNetModelFrom(spec *netSpec.NetSpec) *NetModel {
 		FabricVersionTag:     spec.FabricVersionTag,
 		CaVersionTag:         spec.FabricVersionTag,
 		ThirdpartyVersionTag: spec.ThirdpartyVersionTag,
+		ExplorerVersionTag:   spec.ExplorerVersionTag,
 		ChannelCreationDelay: spec.ChannelCreationDelay,
 		Name:                 spec.Network,
 		Domain:               spec.Domain,
 		Description:          spec.Description,
 		OrdererType:          spec.Orderer.Type,
+		ChannelParticipation: spec.Orderer.ChannelParticipation,
+		Batch:                batch,
 		KafkaBrokers:         kafkaBrokerList,
 		ZooKeeperNodes:       zkNodeList,
 		DBProvider:           spec.DB.Provider,
 		OrdererOrganization:  ordererOrganization,
+		OrdererOrganizations: ordererOrganizationList,
 		Orderers:             ordererList,
 		CAs:                  caList,
 		PeerOrganizations:    peerOrganizationList,
 		Peers:                peerList,
+		Consortiums:          consortiumList,
 		Channels:             channels,
 		Chaincodes:           chaincodeList,
 		LogLevel:             spec.LogLevel,
+		LoggingSpec:          spec.Logging.Spec,
+		FabricLoggingSpec:    spec.FabricLoggingSpec(),
 		TLSEnabled:           spec.TLSEnabled,
+		ChannelPolicies:      buildPolicies(spec.Policies.Channel),
+		ApplicationPolicies:  buildPolicies(spec.Policies.Application),
+		Capabilities: &Capabilities{
+			Channel:     spec.Capabilities.Channel,
+			Orderer:     spec.Capabilities.Orderer,
+			Application: spec.Capabilities.Application,
+		},
+		Observability: observability,
+		Explorer:      explorer,
+		Caliper:       buildCaliper(spec),
+		EmbedPEMs:     spec.ConnectionProfiles.EmbedPEMs,
+	}
+}
+
+//buildObservability allocates operations ports to orderers and then peers, and the monitoring services
+func buildObservability(spec *netSpec.ObservabilitySpec, orderers []*Orderer, peers []*Peer, domain string) *Observability {
+	hostPort := spec.HostPort
+	for _, orderer := range orderers {
+		orderer.OperationsPort = 9443
+		orderer.ExposedOperationsPort = hostPort
+		hostPort++
+	}
+	for _, peer := range peers {
+		peer.OperationsPort = 9443
+		peer.ExposedOperationsPort = hostPort
+		hostPort++
+	}
+
+	observability := &Observability{
+		MetricsProvider: spec.Metrics,
+		StatsdAddress:   spec.StatsdAddress,
+	}
+
+	if spec.Prometheus {
+		observability.Prometheus = &MonitoringService{
+			Name:        fmt.Sprintf("prometheus.%s", domain),
+			Image:       spec.PrometheusImage,
+			ExposedPort: 9090,
+			Port:        9090,
+		}
+	}
+
+	if spec.Grafana {
+		observability.Grafana = &MonitoringService{
+			Name:        fmt.Sprintf("grafana.%s", domain),
+			Image:       spec.GrafanaImage,
+			ExposedPort: 3000,
+			Port:        3000,
+		}
+	}
+
+	return observability
+}
+
+func buildCaliper(spec *netSpec.NetSpec) *Caliper {
+	if !spec.Caliper {
+		return nil
+	}
+
+	//Fabric 2.x networks are driven with the 2.2 SDK, earlier ones with the 1.4 SDK
+	sut := "fabric:2.2"
+	if version := spec.FabricVersion(); version != nil && !version.AtLeast(2, 0, 0) {
+		sut = "fabric:1.4"
+	}
+
+	return &Caliper{SUT: sut}
+}
+
+//explorerOrganization returns the organization joining the most channels, the first one on ties, Explorer follows the
+//channels joined by the peers of its organization
+func explorerOrganization(organizations []*Organization, chSpecs []*netSpec.ChannelSpec) *Organization {
+	var explorerOrg *Organization
+	maxChannels := -1
+	for i, org := range organizations {
+		joined := 0
+		for _, chSpec := range chSpecs {
+			for _, chOrgSpec := range chSpec.Organizations {
+				if chOrgSpec.ID == i+1 {
+					joined++
+					break
+				}
+			}
+		}
+		if joined > maxChannels {
+			explorerOrg, maxChannels = org, joined
+		}
+	}
+	return explorerOrg
+}
+
+func buildPolicies(specs map[string]*netSpec.PolicySpec) map[string]*Policy {
+	policies := make(map[string]*Policy, len(specs))
+	for name, policySpec := range specs {
+		policies[name] = &Policy{Type: policySpec.Type, Rule: policySpec.Rule}
+	}
+	return policies
+}
+
+func buildBatch(spec *netSpec.BatchSpec) *Batch {
+	//Sizes were already checked by spec validation
+	absoluteMaxBytes, _ := netSpec.ParseByteSize(spec.AbsoluteMaxBytes)
+	preferredMaxBytes, _ := netSpec.ParseByteSize(spec.PreferredMaxBytes)
+
+	return &Batch{
+		Timeout:           spec.Timeout,
+		MaxMessageCount:   spec.MaxMessageCount,
+		AbsoluteMaxBytes:  absoluteMaxBytes,
+		PreferredMaxBytes: preferredMaxBytes,
 	}
 }
 
@@ -0,0 +1,88 @@
+package netSpec
+
+import (
+	"fmt"
+	"strings"
+)
+
+type aclResource struct {
+	Name string
+	//Releases supporting the resource, a nil Until means it is still supported
+	Since *FabricVersion
+	Until *FabricVersion
+}
+
+//Resources whose ACL can be set in the channel configuration
+var aclResources = []*aclResource{
+	{"_lifecycle/CheckCommitReadiness", &FabricVersion{2, 0, 0}, nil},
+	{"_lifecycle/CommitChaincodeDefinition", &FabricVersion{2, 0, 0}, nil},
+	{"_lifecycle/QueryChaincodeDefinition", &FabricVersion{2, 0, 0}, nil},
+	{"_lifecycle/QueryChaincodeDefinitions", &FabricVersion{2, 0, 0}, nil},
+	{"lscc/ChaincodeExists", &FabricVersion{1, 2, 0}, nil},
+	{"lscc/GetDeploymentSpec", &FabricVersion{1, 2, 0}, nil},
+	{"lscc/GetChaincodeData", &FabricVersion{1, 2, 0}, nil},
+	{"lscc/GetInstantiatedChaincodes", &FabricVersion{1, 2, 0}, nil},
+	{"qscc/GetChainInfo", &FabricVersion{1, 2, 0}, nil},
+	{"qscc/GetBlockByNumber", &FabricVersion{1, 2, 0}, nil},
+	{"qscc/GetBlockByHash", &FabricVersion{1, 2, 0}, nil},
+	{"qscc/GetTransactionByID", &FabricVersion{1, 2, 0}, nil},
+	{"qscc/GetBlockByTxID", &FabricVersion{1, 2, 0}, nil},
+	{"cscc/GetConfigBlock", &FabricVersion{1, 2, 0}, nil},
+	{"cscc/GetConfigTree", &FabricVersion{1, 2, 0}, &FabricVersion{2, 0, 0}},
+	{"cscc/SimulateConfigTreeUpdate", &FabricVersion{1, 2, 0}, &FabricVersion{2, 0, 0}},
+	{"cscc/GetChannelConfig", &FabricVersion{2, 0, 0}, nil},
+	{"peer/Propose", &FabricVersion{1, 2, 0}, nil},
+	{"peer/ChaincodeToChaincode", &FabricVersion{1, 2, 0}, nil},
+	{"event/Block", &FabricVersion{1, 2, 0}, nil},
+	{"event/FilteredBlock", &FabricVersion{1, 2, 0}, nil},
+}
+
+func validateACLs(acls map[string]string, version *FabricVersion, capabilities *CapabilitiesSpec) error {
+	if len(acls) == 0 {
+		return nil
+	}
+
+	if capabilities.Application != "" && !capabilityAtLeast(applicationCapabilities, capabilities.Application, "V1_2") {
+		return fmt.Errorf("ACLs require application capability V1_2 or later, got '%s'", capabilities.Application)
+	}
+
+	for resource, policy := range acls {
+		if err := validateACLResource(resource, version); err != nil {
+			return err
+		}
+
+		if policy == "" {
+			return fmt.Errorf("no policy specified for resource '%s'", resource)
+		}
+
+		if strings.HasPrefix(policy, "/") && !strings.HasPrefix(policy, "/Channel/") {
+			return fmt.Errorf("invalid policy '%s' for resource '%s', policy paths must start with /Channel/", policy, resource)
+		}
+	}
+
+	return nil
+}
+
+func validateACLResource(name string, version *FabricVersion) error {
+	for _, resource := range aclResources {
+		if resource.Name != name {
+			continue
+		}
+
+		if version == nil {
+			return nil
+		}
+
+		if !version.AtLeast(resource.Since.Major, resource.Since.Minor, resource.Since.Patch) {
+			return fmt.Errorf("resource '%s' requires Fabric %s or later, images are %s", name, resource.Since, version)
+		}
+
+		if resource.Until != nil && version.AtLeast(resource.Until.Major, resource.Until.Minor, resource.Until.Patch) {
+			return fmt.Errorf("resource '%s' is not available since Fabric %s, images are %s", name, resource.Until, version)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown resource '%s'", name)
+}
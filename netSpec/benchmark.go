@@ -0,0 +1,46 @@
+package netSpec
+
+import (
+	"fmt"
+)
+
+//ChaincodeBenchmarkSpec describes the round of the starter Caliper benchmark generated for a chaincode
+type ChaincodeBenchmarkSpec struct {
+	Function string   `yaml:"function"`
+	Args     []string `yaml:"args"`
+	//ReadOnly transactions are evaluated by a peer instead of being submitted for ordering
+	ReadOnly bool `yaml:"readOnly"`
+	Workers  int  `yaml:"workers"`
+	TxNumber int  `yaml:"txNumber"`
+	TPS      int  `yaml:"tps"`
+	//InitArgs are the function and arguments the chaincode is instantiated with before running the benchmark
+	InitArgs []string `yaml:"initArgs"`
+}
+
+func (benchmark *ChaincodeBenchmarkSpec) setDefaults() {
+	if benchmark.Workers == 0 {
+		benchmark.Workers = 1
+	}
+	if benchmark.TxNumber == 0 {
+		benchmark.TxNumber = 100
+	}
+	if benchmark.TPS == 0 {
+		benchmark.TPS = 10
+	}
+	//DEFAULT: initial state expected by the sample chaincodes, accounts a and b
+	if len(benchmark.InitArgs) == 0 {
+		benchmark.InitArgs = []string{"init", "a", "100", "b", "200"}
+	}
+}
+
+func (benchmark *ChaincodeBenchmarkSpec) validate(ccName string) error {
+	if benchmark.Function == "" {
+		return fmt.Errorf("Benchmark of chaincode '%s' has not specified any function", ccName)
+	}
+
+	if benchmark.Workers < 1 || benchmark.TxNumber < 1 || benchmark.TPS < 1 {
+		return fmt.Errorf("Benchmark of chaincode '%s' requires positive workers, txNumber and tps", ccName)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package netSpec
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+//CapabilitiesSpec selects the capability enabled at each configuration level, e.g. V1_4_3
+type CapabilitiesSpec struct {
+	Channel     string `yaml:"channel"`
+	Orderer     string `yaml:"orderer"`
+	Application string `yaml:"application"`
+}
+
+type capability struct {
+	Name       string
+	MinVersion *FabricVersion
+}
+
+/* Capabilities known for each configuration level and the first Fabric release supporting them,
+ * sorted from oldest to newest
+ */
+var (
+	channelCapabilities = []*capability{
+		{"V1_1", &FabricVersion{1, 1, 0}},
+		{"V1_3", &FabricVersion{1, 3, 0}},
+		{"V1_4_2", &FabricVersion{1, 4, 2}},
+		{"V1_4_3", &FabricVersion{1, 4, 3}},
+		{"V2_0", &FabricVersion{2, 0, 0}},
+	}
+
+	ordererCapabilities = []*capability{
+		{"V1_1", &FabricVersion{1, 1, 0}},
+		{"V1_4_2", &FabricVersion{1, 4, 2}},
+		{"V2_0", &FabricVersion{2, 0, 0}},
+	}
+
+	applicationCapabilities = []*capability{
+		{"V1_1", &FabricVersion{1, 1, 0}},
+		{"V1_2", &FabricVersion{1, 2, 0}},
+		{"V1_3", &FabricVersion{1, 3, 0}},
+		{"V1_4_2", &FabricVersion{1, 4, 2}},
+		{"V2_0", &FabricVersion{2, 0, 0}},
+		{"V2_5", &FabricVersion{2, 5, 0}},
+	}
+)
+
+//Application capabilities of the Fabric 2.x chaincode lifecycle and of the last release of the legacy one
+const (
+	lifecycleCapability       string = "V2_0"
+	legacyLifecycleCapability string = "V1_4_2"
+)
+
+func (capabilities *CapabilitiesSpec) setDefaults(version *FabricVersion) {
+	if version == nil {
+		log.Printf("Warning: capabilities can not be derived from FABRIC_VERSION_TAG, configtxgen defaults apply to levels not specified\r\n")
+		return
+	}
+
+	//DEFAULT: latest capability supported by the Fabric release at each level
+	if capabilities.Channel == "" {
+		capabilities.Channel = latestCapability(channelCapabilities, version)
+	}
+	if capabilities.Orderer == "" {
+		capabilities.Orderer = latestCapability(ordererCapabilities, version)
+	}
+	//DEFAULT: application capability capped at the last one of the legacy chaincode lifecycle, provision.sh deploys
+	//chaincodes with peer chaincode install and instantiate, which V2_0 and later reject
+	if capabilities.Application == "" {
+		capabilities.Application = latestCapability(applicationCapabilities, version)
+		if capabilityAtLeast(applicationCapabilities, capabilities.Application, lifecycleCapability) {
+			capabilities.Application = legacyLifecycleCapability
+		}
+	}
+}
+
+func (capabilities *CapabilitiesSpec) validate(version *FabricVersion) error {
+	if err := validateCapability("channel", capabilities.Channel, channelCapabilities, version); err != nil {
+		return err
+	}
+	if err := validateCapability("orderer", capabilities.Orderer, ordererCapabilities, version); err != nil {
+		return err
+	}
+	return validateCapability("application", capabilities.Application, applicationCapabilities, version)
+}
+
+func latestCapability(known []*capability, version *FabricVersion) string {
+	latest := ""
+	for _, c := range known {
+		if version.AtLeast(c.MinVersion.Major, c.MinVersion.Minor, c.MinVersion.Patch) {
+			latest = c.Name
+		}
+	}
+	return latest
+}
+
+func validateCapability(level, name string, known []*capability, version *FabricVersion) error {
+	if name == "" {
+		return nil
+	}
+
+	names := make([]string, len(known))
+	for i, c := range known {
+		names[i] = c.Name
+		if c.Name != name {
+			continue
+		}
+
+		if version != nil && !version.AtLeast(c.MinVersion.Major, c.MinVersion.Minor, c.MinVersion.Patch) {
+			return fmt.Errorf("Capability '%s' at %s level requires Fabric %s or later, images are %s", name, level, c.MinVersion, version)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("Unknown capability '%s' at %s level, expected one of %s", name, level, strings.Join(names, ", "))
+}
+
+//capabilityAtLeast reports whether capability is known and is the same or newer than min at the given level
+func capabilityAtLeast(known []*capability, name, min string) bool {
+	reached := false
+	for _, c := range known {
+		if c.Name == min {
+			reached = true
+		}
+		if c.Name == name {
+			return reached
+		}
+	}
+	return false
+}
@@ -0,0 +1,254 @@
+package netSpec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	yaml "gopkg.in/yaml.v2"
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+//Keys of a spec file naming the base spec files it is composed of, paths are relative to the spec file
+const (
+	extendsKey string = "extends"
+	includeKey string = "include"
+)
+
+//listMergeKeys identify list items merged instead of replaced: channels, chaincodes, orderer organizations and consortiums
+//by name, channel organizations by organization ID, channel peers by peer ID
+var listMergeKeys = []string{"name", "organization", "peer"}
+
+//LoadFromFiles loads a spec file, composed with the specs it extends or includes, merges the overlays on top in order,
+//interpolates variables and applies key=value overrides
+func LoadFromFiles(specFile string, overlays []string, overrides []string) (*NetSpec, error) {
+	merged, version, err := loadComposed(specFile, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	//Overlays without apiVersion are in the format of the spec file
+	for _, overlay := range overlays {
+		overlaySpec, _, err := loadComposed(overlay, nil, version)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeNodes(merged, overlaySpec)
+	}
+
+	if err := interpolate(merged); err != nil {
+		return nil, err
+	}
+
+	if err := applyOverrides(merged, overrides); err != nil {
+		return nil, err
+	}
+
+	//Merged nodes keep scalars as written, e.g. version 1.0, the spec is then decoded as a single file
+	content, err := yaml3.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &NetSpec{}
+	if err := yaml.Unmarshal(content, spec); err != nil {
+		return nil, fmt.Errorf("Error parsing spec composed from '%s': %v", specFile, err)
+	}
+	return spec, nil
+}
+
+//loadComposed reads a spec file as a YAML mapping, merged on top of the base specs it extends or includes, it returns the
+//apiVersion the file is written in, inherited from its last base, or the given one when it has no base
+func loadComposed(specFile string, loading []string, inherited string) (*yaml3.Node, string, error) {
+	specFile, loading, err := enterSpecFile(specFile, loading)
+	if err != nil {
+		return nil, "", err
+	}
+
+	_, tree, err := readSpecDocument(specFile)
+	if err != nil {
+		return nil, "", err
+	}
+
+	bases, err := baseSpecFiles(specFile, tree)
+	if err != nil {
+		return nil, "", err
+	}
+	removeMappingKey(tree, extendsKey)
+	removeMappingKey(tree, includeKey)
+
+	merged := &yaml3.Node{Kind: yaml3.MappingNode, Tag: "!!map"}
+	for _, base := range bases {
+		baseTree, baseVersion, err := loadComposed(base, loading, "")
+		if err != nil {
+			return nil, "", err
+		}
+		merged = mergeNodes(merged, baseTree)
+		inherited = baseVersion
+	}
+
+	migration, err := migrate(tree, inherited)
+	if err != nil {
+		return nil, "", fmt.Errorf("Error migrating spec file '%s': %v", specFile, err)
+	}
+	migration.warn(specFile)
+
+	return mergeNodes(merged, tree), migration.From, nil
+}
+
+//enterSpecFile adds a spec file to the files being loaded, failing when it is already loading
+func enterSpecFile(specFile string, loading []string) (string, []string, error) {
+	specFile = filepath.Clean(specFile)
+	for _, file := range loading {
+		if file == specFile {
+			return "", nil, fmt.Errorf("Spec file '%s' includes itself through %v", specFile, loading)
+		}
+	}
+	return specFile, append(loading, specFile), nil
+}
+
+//readSpecDocument parses a spec file into its document and its top level mapping, empty when the file is empty
+func readSpecDocument(specFile string) (*yaml3.Node, *yaml3.Node, error) {
+	content, err := ioutil.ReadFile(specFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error reading spec file '%s': %v", specFile, err)
+	}
+
+	document := &yaml3.Node{}
+	if err := yaml3.Unmarshal(content, document); err != nil {
+		return nil, nil, fmt.Errorf("Error parsing spec file '%s': %v", specFile, err)
+	}
+
+	if len(document.Content) == 0 {
+		tree := &yaml3.Node{Kind: yaml3.MappingNode, Tag: "!!map"}
+		return &yaml3.Node{Kind: yaml3.DocumentNode, Content: []*yaml3.Node{tree}}, tree, nil
+	}
+	if document.Content[0].Kind != yaml3.MappingNode {
+		return nil, nil, fmt.Errorf("Spec file '%s' is not a YAML mapping", specFile)
+	}
+	return document, document.Content[0], nil
+}
+
+//baseSpecFiles returns the files named by extends, then include, resolved relative to the spec file
+func baseSpecFiles(specFile string, tree *yaml3.Node) ([]string, error) {
+	bases := make([]string, 0)
+	for _, key := range []string{extendsKey, includeKey} {
+		value := mappingValue(tree, key)
+		if value == nil {
+			continue
+		}
+
+		switch value.Kind {
+		case yaml3.ScalarNode:
+			bases = append(bases, value.Value)
+		case yaml3.SequenceNode:
+			for _, item := range value.Content {
+				if item.Kind != yaml3.ScalarNode {
+					return nil, fmt.Errorf("Spec file '%s' has invalid %s entry at line %d", specFile, key, item.Line)
+				}
+				bases = append(bases, item.Value)
+			}
+		default:
+			return nil, fmt.Errorf("Spec file '%s' has invalid %s at line %d, expected a file or a list of files", specFile, key, value.Line)
+		}
+	}
+
+	for i, base := range bases {
+		if !filepath.IsAbs(base) {
+			bases[i] = filepath.Join(filepath.Dir(specFile), base)
+		}
+	}
+	return bases, nil
+}
+
+//mappingValue returns the value of a key in a mapping node, nil when missing
+func mappingValue(mapping *yaml3.Node, key string) *yaml3.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func removeMappingKey(mapping *yaml3.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+//mergeNodes merges overlay on top of base: mappings are merged recursively, lists of items identified by name are merged
+//item by item, any other value is replaced
+func mergeNodes(base, overlay *yaml3.Node) *yaml3.Node {
+	if base == nil || base.Kind != overlay.Kind {
+		return overlay
+	}
+
+	switch overlay.Kind {
+	case yaml3.MappingNode:
+		merged := *base
+		merged.Content = append([]*yaml3.Node{}, base.Content...)
+		for i := 0; i+1 < len(overlay.Content); i += 2 {
+			key, value := overlay.Content[i], overlay.Content[i+1]
+			replaced := false
+			for j := 0; j+1 < len(merged.Content); j += 2 {
+				if merged.Content[j].Value == key.Value {
+					merged.Content[j+1] = mergeNodes(merged.Content[j+1], value)
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				merged.Content = append(merged.Content, key, value)
+			}
+		}
+		return &merged
+	case yaml3.SequenceNode:
+		if key := listMergeKey(base, overlay); key != "" {
+			return mergeList(base, overlay, key)
+		}
+	}
+	return overlay
+}
+
+//listMergeKey returns the key identifying every item of both lists, empty when lists must be replaced
+func listMergeKey(base, overlay *yaml3.Node) string {
+	items := append(append([]*yaml3.Node{}, base.Content...), overlay.Content...)
+	for _, key := range listMergeKeys {
+		identified := true
+		for _, item := range items {
+			if item.Kind != yaml3.MappingNode || mappingValue(item, key) == nil {
+				identified = false
+				break
+			}
+		}
+		if identified {
+			return key
+		}
+	}
+	return ""
+}
+
+//mergeList merges overlay items into the base items with the same key, other overlay items are appended
+func mergeList(base, overlay *yaml3.Node, key string) *yaml3.Node {
+	merged := *base
+	merged.Content = append([]*yaml3.Node{}, base.Content...)
+	for _, item := range overlay.Content {
+		id := mappingValue(item, key).Value
+		found := false
+		for i, baseItem := range merged.Content {
+			if mappingValue(baseItem, key).Value == id {
+				merged.Content[i] = mergeNodes(baseItem, item)
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged.Content = append(merged.Content, item)
+		}
+	}
+	return &merged
+}
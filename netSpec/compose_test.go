@@ -0,0 +1,201 @@
+package netSpec
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//writeSpecFiles writes spec files, indexed by path relative to a temporary directory, and returns the directory
+func writeSpecFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadComposed(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+		err      string
+	}{
+		{
+			name: "maps merged recursively",
+			files: map[string]string{
+				"base.yaml": "orderer: {type: solo, batch: {timeout: 2s}}\ndomain: base.com",
+				"spec.yaml": "extends: base.yaml\norderer: {batch: {maxMessageCount: 20}}",
+			},
+			expected: "orderer: {type: solo, batch: {timeout: 2s, maxMessageCount: 20}}\ndomain: base.com",
+		},
+		{
+			name: "extends then include",
+			files: map[string]string{
+				"base.yaml":  "domain: base.com\nnetwork: base",
+				"extra.yaml": "domain: extra.com\ndescription: extra",
+				"spec.yaml":  "extends: base.yaml\ninclude: [extra.yaml]\nnetwork: spec",
+			},
+			expected: "domain: extra.com\nnetwork: spec\ndescription: extra",
+		},
+		{
+			name: "items merged by name",
+			files: map[string]string{
+				"base.yaml": "channels: [{name: a, batch: {timeout: 2s}}, {name: b}]",
+				"spec.yaml": "extends: base.yaml\nchannels: [{name: a, consortium: c}, {name: d}]",
+			},
+			expected: "channels: [{name: a, batch: {timeout: 2s}, consortium: c}, {name: b}, {name: d}]",
+		},
+		{
+			name: "channel organizations merged by organization, their peers by peer",
+			files: map[string]string{
+				"base.yaml": "channels: [{name: a, organizations: [{organization: 1, peers: [{peer: 1, endorser: true}, {peer: 2}]}, {organization: 2}]}]",
+				"spec.yaml": "extends: base.yaml\nchannels: [{name: a, organizations: [{organization: 1, peers: [{peer: 2, endorser: true}]}]}]",
+			},
+			expected: "channels: [{name: a, organizations: [{organization: 1, peers: [{peer: 1, endorser: true}, {peer: 2, endorser: true}]}, {organization: 2}]}]",
+		},
+		{
+			name: "lists of scalars replaced",
+			files: map[string]string{
+				"base.yaml": "chaincodes: [{name: kv, channels: [a, b]}]",
+				"spec.yaml": "extends: base.yaml\nchaincodes: [{name: kv, channels: [c]}]",
+			},
+			expected: "chaincodes: [{name: kv, channels: [c]}]",
+		},
+		{
+			name: "lists with items without key replaced",
+			files: map[string]string{
+				"base.yaml": "chaincodes: [{name: kv, version: 1.0}]",
+				"spec.yaml": "extends: base.yaml\nchaincodes: [{name: kv, path: kv}, {path: other}]",
+			},
+			expected: "chaincodes: [{name: kv, path: kv}, {path: other}]",
+		},
+		{
+			name: "lists with different keys replaced",
+			files: map[string]string{
+				"base.yaml": "consortiums: [{name: c, organizations: [1]}]",
+				"spec.yaml": "extends: base.yaml\nconsortiums: [{organization: 1}]",
+			},
+			expected: "consortiums: [{organization: 1}]",
+		},
+		{
+			name: "values of another kind replaced",
+			files: map[string]string{
+				"base.yaml": "logging: {spec: debug}",
+				"spec.yaml": "extends: base.yaml\nlogging: null",
+			},
+			expected: "logging: null",
+		},
+		{
+			name:  "self extension",
+			files: map[string]string{"spec.yaml": "extends: spec.yaml"},
+			err:   "includes itself",
+		},
+		{
+			name: "include cycle",
+			files: map[string]string{
+				"base.yaml":  "include: extra.yaml",
+				"extra.yaml": "extends: [base.yaml]",
+				"spec.yaml":  "extends: base.yaml",
+			},
+			err: "includes itself",
+		},
+		{
+			name:  "missing base",
+			files: map[string]string{"spec.yaml": "extends: missing.yaml"},
+			err:   "Error reading spec file",
+		},
+		{
+			name:  "invalid extends",
+			files: map[string]string{"spec.yaml": "extends: {file: base.yaml}"},
+			err:   "has invalid extends at line 1",
+		},
+	}
+
+	for _, test := range tests {
+		dir := writeSpecFiles(t, test.files)
+		tree, _, err := loadComposed(filepath.Join(dir, "spec.yaml"), nil, "")
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: got error %v, expected %s", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+
+		actual := decodeTree(t, tree)
+		delete(actual, apiVersionKey)
+		if expected := decodeYAML(t, test.expected); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s: got %v, expected %v", test.name, actual, expected)
+		}
+	}
+}
+
+func TestLoadComposedVersion(t *testing.T) {
+	files := map[string]string{
+		"v1.yaml":         "logLevel: debug",
+		"v2.yaml":         "apiVersion: netcomposer/v2\ndomain: v2.com",
+		"extends-v1.yaml": "extends: v1.yaml\ndescription: fragment",
+		"extends-v2.yaml": "extends: v2.yaml\nlogLevel: debug",
+		"overlay.yaml":    "logLevel: info",
+	}
+	tests := []struct {
+		name      string
+		file      string
+		inherited string
+		version   string
+		expected  string
+	}{
+		{"no apiVersion", "v1.yaml", "", APIVersionV1, "logging: {spec: debug}"},
+		{"apiVersion", "v2.yaml", "", APIVersionV2, "domain: v2.com"},
+		{"inherited from a v1 base", "extends-v1.yaml", "", APIVersionV1, "logging: {spec: debug}\ndescription: fragment"},
+		{"inherited from a v2 base", "extends-v2.yaml", "", APIVersionV2, "domain: v2.com\nlogLevel: debug"},
+		{"overlay of a v2 spec", "overlay.yaml", APIVersionV2, APIVersionV2, "logLevel: info"},
+		{"overlay of a v1 spec", "overlay.yaml", APIVersionV1, APIVersionV1, "logging: {spec: info}"},
+	}
+
+	dir := writeSpecFiles(t, files)
+	for _, test := range tests {
+		tree, version, err := loadComposed(filepath.Join(dir, test.file), nil, test.inherited)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if version != test.version {
+			t.Errorf("%s: got version %s, expected %s", test.name, version, test.version)
+		}
+
+		actual := decodeTree(t, tree)
+		delete(actual, apiVersionKey)
+		if expected := decodeYAML(t, test.expected); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s: got %v, expected %v", test.name, actual, expected)
+		}
+	}
+}
+
+func TestLoadFromFiles(t *testing.T) {
+	dir := writeSpecFiles(t, map[string]string{
+		"spec.yaml":    "apiVersion: netcomposer/v2\nnetwork: net\nchannels: [{name: a}]\norderer: {type: solo}",
+		"overlay.yaml": "channels: [{name: a, consortium: c}, {name: b}]\norderer: {consenters: 3}",
+	})
+
+	spec, err := LoadFromFiles(filepath.Join(dir, "spec.yaml"), []string{filepath.Join(dir, "overlay.yaml")}, []string{"orderer.type=etcdraft"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if spec.APIVersion != APIVersion || spec.Network != "net" || spec.Orderer.Type != OrderingServiceEtcdRaft || spec.Orderer.Consenters != 3 {
+		t.Errorf("Got apiVersion %s, network %s, orderer %s with %d consenters", spec.APIVersion, spec.Network, spec.Orderer.Type, spec.Orderer.Consenters)
+	}
+	if len(spec.Channels) != 2 || spec.Channels[0].Consortium != "c" || spec.Channels[1].Name != "b" {
+		t.Errorf("Got channels %v", spec.Channels)
+	}
+}
@@ -0,0 +1,150 @@
+package netSpec
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+//variableRegexp matches ${VAR} and ${VAR:-default}, $${ escapes a literal ${
+var variableRegexp = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+//interpolate replaces variables in every scalar value of the spec with environment variables or their defaults
+func interpolate(node *yaml3.Node) error {
+	undefined := make(map[string]bool)
+	interpolateNode(node, undefined)
+
+	if len(undefined) > 0 {
+		names := make([]string, 0, len(undefined))
+		for name := range undefined {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("Undefined variables without default: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+func interpolateNode(node *yaml3.Node, undefined map[string]bool) {
+	switch node.Kind {
+	case yaml3.MappingNode:
+		//Keys are left as written
+		for i := 1; i < len(node.Content); i += 2 {
+			interpolateNode(node.Content[i], undefined)
+		}
+	case yaml3.SequenceNode, yaml3.DocumentNode:
+		for _, item := range node.Content {
+			interpolateNode(item, undefined)
+		}
+	case yaml3.ScalarNode:
+		if !strings.Contains(node.Value, "${") {
+			return
+		}
+		node.Value = variableRegexp.ReplaceAllStringFunc(node.Value, func(match string) string {
+			if match == "$${" {
+				return "${"
+			}
+
+			groups := variableRegexp.FindStringSubmatch(match)
+			if value, found := os.LookupEnv(groups[1]); found && (value != "" || groups[2] == "") {
+				return value
+			}
+			if groups[2] != "" {
+				return groups[3]
+			}
+			undefined[groups[1]] = true
+			return ""
+		})
+		//Plain scalars are resolved again, e.g. consenters: ${CONSENTERS:-3} is decoded as a number
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+	}
+}
+
+//applyOverrides sets spec values addressed by YAML path, e.g. orderer.batch.timeout=5s or channels.bigchannel.batch.timeout=5s,
+//list items are addressed by name, organization ID or index
+func applyOverrides(tree *yaml3.Node, overrides []string) error {
+	for _, override := range overrides {
+		eq := strings.IndexByte(override, '=')
+		if eq <= 0 {
+			return fmt.Errorf("Invalid override '%s', expected key=value", override)
+		}
+
+		value := &yaml3.Node{}
+		if err := yaml3.Unmarshal([]byte(override[eq+1:]), value); err != nil {
+			return fmt.Errorf("Invalid value of override '%s': %v", override, err)
+		}
+		if len(value.Content) == 0 {
+			value = &yaml3.Node{Kind: yaml3.ScalarNode, Tag: "!!null", Value: "null"}
+		} else {
+			value = value.Content[0]
+		}
+
+		if err := setPath(tree, strings.Split(override[:eq], "."), value); err != nil {
+			return fmt.Errorf("Invalid override '%s': %v", override, err)
+		}
+	}
+	return nil
+}
+
+func setPath(node *yaml3.Node, path []string, value *yaml3.Node) error {
+	key := path[0]
+	if key == "" {
+		return fmt.Errorf("empty key")
+	}
+
+	switch node.Kind {
+	case yaml3.MappingNode:
+		child := mappingValue(node, key)
+		if len(path) == 1 {
+			if child != nil {
+				*child = *value
+			} else {
+				node.Content = append(node.Content, &yaml3.Node{Kind: yaml3.ScalarNode, Tag: "!!str", Value: key}, value)
+			}
+			return nil
+		}
+		if child == nil || (child.Kind == yaml3.ScalarNode && child.Tag == "!!null") {
+			child = &yaml3.Node{Kind: yaml3.MappingNode, Tag: "!!map"}
+			removeMappingKey(node, key)
+			node.Content = append(node.Content, &yaml3.Node{Kind: yaml3.ScalarNode, Tag: "!!str", Value: key}, child)
+		}
+		return setPath(child, path[1:], value)
+	case yaml3.SequenceNode:
+		item := sequenceItem(node, key)
+		if item == nil {
+			return fmt.Errorf("no list item '%s'", key)
+		}
+		if len(path) == 1 {
+			*item = *value
+			return nil
+		}
+		return setPath(item, path[1:], value)
+	}
+	return fmt.Errorf("'%s' is not a map nor a list", key)
+}
+
+//sequenceItem returns the list item identified by a merge key, e.g. its name, or by its index
+func sequenceItem(sequence *yaml3.Node, key string) *yaml3.Node {
+	for _, item := range sequence.Content {
+		if item.Kind != yaml3.MappingNode {
+			continue
+		}
+		for _, mergeKey := range listMergeKeys {
+			if id := mappingValue(item, mergeKey); id != nil && id.Value == key {
+				return item
+			}
+		}
+	}
+
+	if index, err := strconv.Atoi(key); err == nil && index >= 0 && index < len(sequence.Content) {
+		return sequence.Content[index]
+	}
+	return nil
+}
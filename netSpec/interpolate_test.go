@@ -0,0 +1,215 @@
+package netSpec
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+//setenv sets an environment variable for the duration of a test, unset when value is nil
+func setenv(t *testing.T, name string, value *string) {
+	previous, found := os.LookupEnv(name)
+	if value == nil {
+		os.Unsetenv(name)
+	} else {
+		os.Setenv(name, *value)
+	}
+	t.Cleanup(func() {
+		if found {
+			os.Setenv(name, previous)
+		} else {
+			os.Unsetenv(name)
+		}
+	})
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+//parseTree parses YAML content into its top level mapping
+func parseTree(t *testing.T, content string) *yaml3.Node {
+	document := &yaml3.Node{}
+	if err := yaml3.Unmarshal([]byte(content), document); err != nil {
+		t.Fatalf("Error parsing %q: %v", content, err)
+	}
+	if len(document.Content) == 0 {
+		return &yaml3.Node{Kind: yaml3.MappingNode, Tag: "!!map"}
+	}
+	return document.Content[0]
+}
+
+//decodeTree decodes a tree as the spec loader does, through yaml.v2
+func decodeTree(t *testing.T, tree *yaml3.Node) map[interface{}]interface{} {
+	content, err := yaml3.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal(content, &decoded); err != nil {
+		t.Fatalf("Error decoding %s: %v", content, err)
+	}
+	return decoded
+}
+
+func decodeYAML(t *testing.T, content string) map[interface{}]interface{} {
+	decoded := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal([]byte(content), &decoded); err != nil {
+		t.Fatalf("Error decoding %q: %v", content, err)
+	}
+	return decoded
+}
+
+func TestInterpolate(t *testing.T) {
+	setenv(t, "NC_TEST_SET", stringPtr("value"))
+	setenv(t, "NC_TEST_EMPTY", stringPtr(""))
+	setenv(t, "NC_TEST_NUMBER", stringPtr("5"))
+	setenv(t, "NC_TEST_UNSET", nil)
+	setenv(t, "NC_TEST_OTHER", nil)
+
+	tests := []struct {
+		name     string
+		spec     string
+		expected string
+		err      string
+	}{
+		{"set variable", "domain: ${NC_TEST_SET}.com", "domain: value.com", ""},
+		{"default of unset variable", "domain: ${NC_TEST_UNSET:-sample}.com", "domain: sample.com", ""},
+		{"default of empty variable", "domain: ${NC_TEST_EMPTY:-sample}.com", "domain: sample.com", ""},
+		{"empty variable without default", "domain: x${NC_TEST_EMPTY}", "domain: x", ""},
+		{"empty plain default is null", "domain: ${NC_TEST_UNSET:-}", "domain: null", ""},
+		{"empty quoted default", "domain: '${NC_TEST_UNSET:-}'", "domain: ''", ""},
+		{"escaped variable", "description: price $${NC_TEST_SET} for ${NC_TEST_SET}", "description: price ${NC_TEST_SET} for value", ""},
+		{"variables in lists", "channels: [{name: '${NC_TEST_SET}'}]", "channels: [{name: value}]", ""},
+		{"keys are kept", "${NC_TEST_SET}: 1", "${NC_TEST_SET}: 1", ""},
+		{"plain scalar is retyped", "consenters: ${NC_TEST_NUMBER}", "consenters: 5", ""},
+		{"plain default is retyped", "tlsEnabled: ${NC_TEST_UNSET:-true}", "tlsEnabled: true", ""},
+		{"quoted scalar stays a string", "version: \"${NC_TEST_NUMBER}\"", "version: \"5\"", ""},
+		{"undefined variables", "a: ${NC_TEST_UNSET}\nb:\n  - ${NC_TEST_OTHER}\n  - ${NC_TEST_UNSET}", "", "Undefined variables without default: NC_TEST_OTHER, NC_TEST_UNSET"},
+	}
+
+	for _, test := range tests {
+		tree := parseTree(t, test.spec)
+		err := interpolate(tree)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: got error %v, expected %s", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if actual, expected := decodeTree(t, tree), decodeYAML(t, test.expected); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s: got %v, expected %v", test.name, actual, expected)
+		}
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	spec := `
+orderer:
+    type: solo
+    batch: null
+channels:
+    - name: bigchannel
+      organizations:
+        - organization: 2
+          peers:
+            - peer: 1
+            - peer: 3
+            - peer: 4
+    - name: smallchannel
+chaincodes:
+    - name: kv
+      channels: [bigchannel]
+`
+	tests := []struct {
+		name      string
+		overrides []string
+		expected  string
+		err       string
+	}{
+		{"map value", []string{"orderer.type=etcdraft"}, "orderer: {type: etcdraft}", ""},
+		{"value parsed as YAML", []string{"chaincodes.kv.channels=[bigchannel, smallchannel]"}, "chaincodes: [{name: kv, channels: [bigchannel, smallchannel]}]", ""},
+		{"empty value", []string{"orderer.type="}, "orderer: {type: null}", ""},
+		{"missing maps are created", []string{"observability.prometheus=true"}, "observability: {prometheus: true}", ""},
+		{"null map is replaced", []string{"orderer.batch.timeout=5s"}, "orderer: {batch: {timeout: 5s}}", ""},
+		{"item by name", []string{"channels.smallchannel.batch.maxMessageCount=20"}, "channels: [{name: smallchannel, batch: {maxMessageCount: 20}}]", ""},
+		{"item by index", []string{"channels.1.consortium=c"}, "channels: [{name: smallchannel, consortium: c}]", ""},
+		{"item by organization ID", []string{"channels.bigchannel.organizations.2.peers=[]"}, "channels: [{name: bigchannel, organizations: [{organization: 2, peers: []}]}]", ""},
+		{"peer ID takes precedence over index", []string{"channels.bigchannel.organizations.2.peers.1.endorser=true"}, "channels: [{name: bigchannel, organizations: [{organization: 2, peers: [{peer: 1, endorser: true}]}]}]", ""},
+		{"index when no peer has the ID", []string{"channels.bigchannel.organizations.2.peers.2.endorser=true"}, "channels: [{name: bigchannel, organizations: [{organization: 2, peers: [{peer: 4, endorser: true}]}]}]", ""},
+		{"item replaced", []string{"channels.smallchannel={name: tinychannel}"}, "channels: [{name: tinychannel}]", ""},
+		{"unknown item", []string{"channels.nochannel.batch.timeout=5s"}, "", "Invalid override 'channels.nochannel.batch.timeout=5s': no list item 'nochannel'"},
+		{"index out of range", []string{"channels.2.consortium=c"}, "", "Invalid override 'channels.2.consortium=c': no list item '2'"},
+		{"path through a scalar", []string{"orderer.type.name=x"}, "", "Invalid override 'orderer.type.name=x': 'name' is not a map nor a list"},
+		{"missing value", []string{"orderer.type"}, "", "Invalid override 'orderer.type', expected key=value"},
+		{"empty key", []string{"orderer..type=solo"}, "", "Invalid override 'orderer..type=solo': empty key"},
+	}
+
+	for _, test := range tests {
+		tree := parseTree(t, spec)
+		err := applyOverrides(tree, test.overrides)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: got error %v, expected %s", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+
+		actual := decodeTree(t, tree)
+		if !containsYAML(actual, decodeYAML(t, test.expected)) {
+			t.Errorf("%s: got %v, expected it to contain %s", test.name, actual, test.expected)
+		}
+	}
+}
+
+//containsYAML reports whether every value of expected is found in actual, list items are looked up among the actual
+//items, so expectations only list the items and keys a test changes
+func containsYAML(actual, expected interface{}) bool {
+	switch expectedValue := expected.(type) {
+	case map[interface{}]interface{}:
+		actualMap, ok := actual.(map[interface{}]interface{})
+		if !ok {
+			return false
+		}
+		for key, value := range expectedValue {
+			actualValue, found := actualMap[key]
+			if !found || !containsYAML(actualValue, value) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		actualList, ok := actual.([]interface{})
+		if !ok {
+			return false
+		}
+		if len(expectedValue) == 0 {
+			return len(actualList) == 0
+		}
+		for _, item := range expectedValue {
+			found := false
+			for _, actualItem := range actualList {
+				if containsYAML(actualItem, item) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(actual, expected)
+}
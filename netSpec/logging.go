@@ -0,0 +1,157 @@
+package netSpec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//LoggingSpec holds FABRIC_LOGGING_SPEC values, e.g. gossip=debug:info, for the network, organizations and nodes
+type LoggingSpec struct {
+	Spec string `yaml:"spec"`
+	//Organizations are indexed by organization name, e.g. org1 or ordererOrg
+	Organizations map[string]string `yaml:"organizations"`
+	//Nodes are indexed by container name, e.g. peer1.org1.samplenet.com
+	Nodes map[string]string `yaml:"nodes"`
+}
+
+//logLevels are the levels of FABRIC_LOGGING_SPEC, Fabric 1.4 and later
+var logLevels = map[string]bool{
+	"debug":   true,
+	"info":    true,
+	"warn":    true,
+	"warning": true,
+	"error":   true,
+	"panic":   true,
+	"fatal":   true,
+}
+
+//legacyLogLevels are the levels of CORE_LOGGING_LEVEL and ORDERER_GENERAL_LOGLEVEL, releases before Fabric 1.4
+var legacyLogLevels = map[string]bool{
+	"critical": true,
+	"error":    true,
+	"warning":  true,
+	"notice":   true,
+	"info":     true,
+	"debug":    true,
+}
+
+var loggerNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.\-/]+$`)
+
+func (logging *LoggingSpec) setDefaults(logLevel string) {
+	//DEFAULT: network spec is the logLevel, Fabric default otherwise
+	if logging.Spec == "" {
+		logging.Spec = logLevel
+	}
+	if logging.Spec == "" {
+		logging.Spec = "info"
+	}
+}
+
+//FabricLoggingSpec reports whether nodes take FABRIC_LOGGING_SPEC, introduced in Fabric 1.4, assumed when the release is unknown
+func (spec *NetSpec) FabricLoggingSpec() bool {
+	version := spec.FabricVersion()
+	return version == nil || version.AtLeast(1, 4, 0)
+}
+
+func (logging *LoggingSpec) validate(spec *NetSpec) error {
+	moduleSpecs := spec.FabricLoggingSpec()
+
+	if err := validateLoggingSpec(logging.Spec, moduleSpecs); err != nil {
+		return fmt.Errorf("Invalid network logging spec: %v", err)
+	}
+
+	orgNames := make(map[string]bool)
+	for _, orgName := range spec.OrganizationNames() {
+		orgNames[orgName] = true
+	}
+
+	for orgName, orgSpec := range logging.Organizations {
+		if !orgNames[orgName] {
+			return fmt.Errorf("Logging spec specified for unknown organization '%s'", orgName)
+		}
+		if err := validateLoggingSpec(orgSpec, moduleSpecs); err != nil {
+			return fmt.Errorf("Invalid logging spec for organization '%s': %v", orgName, err)
+		}
+	}
+
+	nodeNames := make(map[string]bool)
+	for _, nodeName := range spec.NodeNames() {
+		nodeNames[nodeName] = true
+	}
+
+	for nodeName, nodeSpec := range logging.Nodes {
+		if !nodeNames[nodeName] {
+			return fmt.Errorf("Logging spec specified for unknown node '%s'", nodeName)
+		}
+		if err := validateLoggingSpec(nodeSpec, moduleSpecs); err != nil {
+			return fmt.Errorf("Invalid logging spec for node '%s': %v", nodeName, err)
+		}
+	}
+
+	return nil
+}
+
+/* validateLoggingSpec checks the FABRIC_LOGGING_SPEC syntax:
+ *   spec := [<logger>[,<logger>...]=]<level>[:[<logger>[,<logger>...]=]<level>...]
+ * Releases before 1.4 only accept a plain level, named as in CORE_LOGGING_LEVEL
+ */
+func validateLoggingSpec(loggingSpec string, moduleSpecs bool) error {
+	if loggingSpec == "" {
+		return fmt.Errorf("empty logging spec")
+	}
+
+	for _, term := range strings.Split(loggingSpec, ":") {
+		level := term
+		if eq := strings.IndexByte(term, '='); eq >= 0 {
+			if !moduleSpecs {
+				return fmt.Errorf("logger specific levels in '%s' require Fabric 1.4 or later", loggingSpec)
+			}
+
+			level = term[eq+1:]
+			for _, logger := range strings.Split(term[:eq], ",") {
+				if !loggerNameRegexp.MatchString(logger) {
+					return fmt.Errorf("invalid logger name '%s' in '%s'", logger, loggingSpec)
+				}
+			}
+		} else if !moduleSpecs && term != loggingSpec {
+			return fmt.Errorf("'%s' is not a single level, required by Fabric releases before 1.4", loggingSpec)
+		}
+
+		if !moduleSpecs && !legacyLogLevels[strings.ToLower(level)] {
+			return fmt.Errorf("invalid level '%s', Fabric releases before 1.4 accept critical, error, warning, notice, info and debug", level)
+		}
+		if moduleSpecs && !logLevels[strings.ToLower(level)] {
+			return fmt.Errorf("invalid level '%s' in '%s'", level, loggingSpec)
+		}
+	}
+
+	return nil
+}
+
+//NodeNames returns the container names of every orderer and peer in the network
+func (spec *NetSpec) NodeNames() []string {
+	names := make([]string, 0, spec.Orderer.Consenters+spec.PeerOrgs*spec.PeersPerOrg)
+	for _, ordOrgSpec := range spec.Orderer.Organizations {
+		for i := 0; i < ordOrgSpec.Consenters; i++ {
+			names = append(names, fmt.Sprintf("orderer%d.%s", i+1, ordOrgSpec.Domain))
+		}
+	}
+	for i := 0; i < spec.PeerOrgs; i++ {
+		for j := 0; j < spec.PeersPerOrg; j++ {
+			names = append(names, fmt.Sprintf("peer%d.%s.%s", j+1, PeerOrgName(i+1), spec.Domain))
+		}
+	}
+	return names
+}
+
+//NodeLoggingSpec resolves the logging spec of a node, node specs take precedence over organization specs
+func (logging *LoggingSpec) NodeLoggingSpec(orgName, nodeName string) string {
+	if nodeSpec, ok := logging.Nodes[nodeName]; ok {
+		return nodeSpec
+	}
+	if orgSpec, ok := logging.Organizations[orgName]; ok {
+		return orgSpec
+	}
+	return logging.Spec
+}
@@ -0,0 +1,207 @@
+package netSpec
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+//Spec formats, spec files without apiVersion are in the format of the files they extend or include, netcomposer/v1
+//when they extend or include none
+const (
+	APIVersionV1 string = "netcomposer/v1"
+	APIVersionV2 string = "netcomposer/v2"
+
+	//APIVersion is the latest spec format, spec files in older formats are migrated to it when loaded
+	APIVersion string = APIVersionV2
+
+	apiVersionKey string = "apiVersion"
+)
+
+//migration upgrades a spec file from an apiVersion to the next one, apply returns a description of every change made
+type migration struct {
+	from  string
+	to    string
+	apply func(tree *yaml3.Node) []string
+}
+
+//migrations are chained in order from the apiVersion of a spec file to the latest one
+var migrations = []*migration{
+	//netcomposer/v2 replaces logLevel with the network logging spec
+	{from: APIVersionV1, to: APIVersionV2, apply: migrateLogLevel},
+}
+
+//Migration reports how a spec file was upgraded to the latest apiVersion
+type Migration struct {
+	From    string
+	To      string
+	Changes []string
+}
+
+//APIVersions returns every supported spec format, oldest first
+func APIVersions() []string {
+	versions := []string{APIVersionV1}
+	for _, m := range migrations {
+		versions = append(versions, m.to)
+	}
+	return versions
+}
+
+//MigrateFile upgrades a spec file to the latest apiVersion, it returns the migrated content, keeping comments of the file
+func MigrateFile(specFile string) ([]byte, *Migration, error) {
+	document, tree, err := readSpecDocument(specFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bases, err := baseSpecFiles(specFile, tree)
+	if err != nil {
+		return nil, nil, err
+	}
+	inherited := ""
+	for _, base := range bases {
+		if inherited, err = specVersion(base, []string{filepath.Clean(specFile)}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	migration, err := migrate(tree, inherited)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error migrating spec file '%s': %v", specFile, err)
+	}
+
+	var content bytes.Buffer
+	encoder := yaml3.NewEncoder(&content)
+	encoder.SetIndent(4)
+	if err := encoder.Encode(document); err != nil {
+		return nil, nil, fmt.Errorf("Error writing spec file '%s': %v", specFile, err)
+	}
+	encoder.Close()
+
+	return content.Bytes(), migration, nil
+}
+
+//specVersion returns the apiVersion of a spec file, or the one it inherits from the last file it extends or includes
+func specVersion(specFile string, loading []string) (string, error) {
+	specFile, loading, err := enterSpecFile(specFile, loading)
+	if err != nil {
+		return "", err
+	}
+
+	_, tree, err := readSpecDocument(specFile)
+	if err != nil {
+		return "", err
+	}
+	if value := mappingValue(tree, apiVersionKey); value != nil {
+		return value.Value, nil
+	}
+
+	bases, err := baseSpecFiles(specFile, tree)
+	if err != nil {
+		return "", err
+	}
+	version := APIVersionV1
+	for _, base := range bases {
+		if version, err = specVersion(base, loading); err != nil {
+			return "", err
+		}
+	}
+	return version, nil
+}
+
+//migrate upgrades a spec file mapping in place to the latest apiVersion. A mapping without apiVersion is in the inherited
+//version, netcomposer/v1 when it inherits none, apiVersion is only written into mappings which do not inherit it
+func migrate(tree *yaml3.Node, inherited string) (*Migration, error) {
+	from := inherited
+	if from == "" {
+		from = APIVersionV1
+	}
+	value := mappingValue(tree, apiVersionKey)
+	if value != nil {
+		from = value.Value
+	}
+
+	result := &Migration{From: from, To: APIVersion}
+	version := from
+	for _, m := range migrations {
+		if m.from == version {
+			result.Changes = append(result.Changes, m.apply(tree)...)
+			version = m.to
+		}
+	}
+
+	if version != APIVersion {
+		return nil, fmt.Errorf("Unsupported apiVersion '%s', expected one of %s", from, strings.Join(APIVersions(), ", "))
+	}
+
+	if value != nil {
+		*value = yaml3.Node{Kind: yaml3.ScalarNode, Tag: "!!str", Value: APIVersion, LineComment: value.LineComment}
+	} else if inherited == "" {
+		tree.Content = append([]*yaml3.Node{
+			{Kind: yaml3.ScalarNode, Tag: "!!str", Value: apiVersionKey},
+			{Kind: yaml3.ScalarNode, Tag: "!!str", Value: APIVersion},
+		}, tree.Content...)
+	}
+
+	return result, nil
+}
+
+//warn logs the changes made to a spec file loaded in an older format, files without any change are not reported
+func (migration *Migration) warn(specFile string) {
+	if len(migration.Changes) == 0 {
+		return
+	}
+
+	log.Printf("Warning: spec file '%s' is %s, migrated to %s in memory, run 'netcomposer migrate -spec %s' to update it\r\n",
+		specFile, migration.From, migration.To, specFile)
+	for _, change := range migration.Changes {
+		log.Printf("Warning:   %s\r\n", change)
+	}
+}
+
+//migrateLogLevel moves logLevel to logging.spec, unless the spec file already sets logging.spec, which took precedence
+func migrateLogLevel(tree *yaml3.Node) []string {
+	index := mappingIndex(tree, "logLevel")
+	if index < 0 {
+		return nil
+	}
+	key, logLevel := tree.Content[index], tree.Content[index+1]
+	tree.Content = append(tree.Content[:index:index], tree.Content[index+2:]...)
+
+	logging := mappingValue(tree, "logging")
+	if logging != nil && logging.Kind == yaml3.MappingNode && mappingValue(logging, "spec") != nil {
+		return []string{"logLevel removed, logging.spec takes precedence"}
+	}
+
+	//The logging section takes the place and the comments of logLevel
+	if logging == nil || logging.Kind != yaml3.MappingNode {
+		if loggingIndex := mappingIndex(tree, "logging"); loggingIndex >= 0 {
+			if loggingIndex < index {
+				index -= 2
+			}
+			removeMappingKey(tree, "logging")
+		}
+		logging = &yaml3.Node{Kind: yaml3.MappingNode, Tag: "!!map"}
+		loggingKey := &yaml3.Node{Kind: yaml3.ScalarNode, Tag: "!!str", Value: "logging", HeadComment: key.HeadComment}
+		tree.Content = append(tree.Content[:index], append([]*yaml3.Node{loggingKey, logging}, tree.Content[index:]...)...)
+		key.HeadComment = ""
+	}
+
+	key.Value = "spec"
+	logging.Content = append([]*yaml3.Node{key, logLevel}, logging.Content...)
+	return []string{"logLevel moved to logging.spec"}
+}
+
+//mappingIndex returns the index of a key in a mapping node, -1 when missing
+func mappingIndex(mapping *yaml3.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,163 @@
+package netSpec
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      string
+		inherited string
+		expected  string
+		changes   []string
+		err       string
+	}{
+		{
+			name:     "logLevel only",
+			spec:     "network: net\nlogLevel: debug",
+			expected: "apiVersion: netcomposer/v2\nnetwork: net\nlogging: {spec: debug}",
+			changes:  []string{"logLevel moved to logging.spec"},
+		},
+		{
+			name:     "logLevel with logging.spec",
+			spec:     "logLevel: debug\nlogging: {spec: info}",
+			expected: "apiVersion: netcomposer/v2\nlogging: {spec: info}",
+			changes:  []string{"logLevel removed, logging.spec takes precedence"},
+		},
+		{
+			name:     "logLevel with logging without spec",
+			spec:     "logLevel: debug\nlogging: {format: json}",
+			expected: "apiVersion: netcomposer/v2\nlogging: {spec: debug, format: json}",
+			changes:  []string{"logLevel moved to logging.spec"},
+		},
+		{
+			name:     "logging not a mapping",
+			spec:     "logging: info\nlogLevel: debug",
+			expected: "apiVersion: netcomposer/v2\nlogging: {spec: debug}",
+			changes:  []string{"logLevel moved to logging.spec"},
+		},
+		{
+			name:     "declared v1",
+			spec:     "apiVersion: netcomposer/v1\nlogLevel: debug",
+			expected: "apiVersion: netcomposer/v2\nlogging: {spec: debug}",
+			changes:  []string{"logLevel moved to logging.spec"},
+		},
+		{
+			name:     "v1 without logLevel",
+			spec:     "network: net",
+			expected: "apiVersion: netcomposer/v2\nnetwork: net",
+		},
+		{
+			name:     "v2 is not migrated",
+			spec:     "apiVersion: netcomposer/v2\nlogLevel: debug",
+			expected: "apiVersion: netcomposer/v2\nlogLevel: debug",
+		},
+		{
+			name:      "inherited v2",
+			spec:      "logLevel: debug",
+			inherited: APIVersionV2,
+			expected:  "logLevel: debug",
+		},
+		{
+			name:      "inherited v1 is not stamped",
+			spec:      "logLevel: debug",
+			inherited: APIVersionV1,
+			expected:  "logging: {spec: debug}",
+			changes:   []string{"logLevel moved to logging.spec"},
+		},
+		{
+			name: "unknown apiVersion",
+			spec: "apiVersion: netcomposer/v9",
+			err:  "Unsupported apiVersion 'netcomposer/v9', expected one of netcomposer/v1, netcomposer/v2",
+		},
+	}
+
+	for _, test := range tests {
+		tree := parseTree(t, test.spec)
+		migration, err := migrate(tree, test.inherited)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: got error %v, expected %s", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+
+		if actual, expected := decodeTree(t, tree), decodeYAML(t, test.expected); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s: got %v, expected %v", test.name, actual, expected)
+		}
+		if migration.To != APIVersion || !reflect.DeepEqual(migration.Changes, test.changes) {
+			t.Errorf("%s: got migration to %s with changes %q, expected %q", test.name, migration.To, migration.Changes, test.changes)
+		}
+	}
+}
+
+func TestMigrateLogLevelComments(t *testing.T) {
+	tree := parseTree(t, "network: net\n#Peer logging\nlogLevel: debug #verbose\nlogging: info\n")
+	if _, err := migrate(tree, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := yaml3.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "apiVersion: netcomposer/v2\nnetwork: net\n#Peer logging\nlogging:\n    spec: debug #verbose\n"
+	if string(content) != expected {
+		t.Errorf("Got\n%s\nexpected\n%s", content, expected)
+	}
+}
+
+func TestMigrateFile(t *testing.T) {
+	dir := writeSpecFiles(t, map[string]string{
+		"v1.yaml":         "#Base network\nnetwork: net\nlogLevel: debug\n",
+		"v2.yaml":         "apiVersion: netcomposer/v2\nnetwork: net\n",
+		"extends-v1.yaml": "extends: v1.yaml\nlogLevel: info\n",
+		"extends-v2.yaml": "extends: v2.yaml\nlogLevel: info\n",
+		"cycle.yaml":      "extends: cycle.yaml\n",
+	})
+
+	tests := []struct {
+		file     string
+		expected string
+		from     string
+		changes  int
+		err      string
+	}{
+		{"v1.yaml", "apiVersion: netcomposer/v2\n#Base network\nnetwork: net\nlogging:\n    spec: debug\n", APIVersionV1, 1, ""},
+		{"v2.yaml", "apiVersion: netcomposer/v2\nnetwork: net\n", APIVersionV2, 0, ""},
+		{"extends-v1.yaml", "extends: v1.yaml\nlogging:\n    spec: info\n", APIVersionV1, 1, ""},
+		{"extends-v2.yaml", "extends: v2.yaml\nlogLevel: info\n", APIVersionV2, 0, ""},
+		{"cycle.yaml", "", "", 0, "includes itself"},
+	}
+
+	for _, test := range tests {
+		content, migration, err := MigrateFile(filepath.Join(dir, test.file))
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: got error %v, expected %s", test.file, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.file, err)
+			continue
+		}
+
+		if string(content) != test.expected {
+			t.Errorf("%s: got\n%s\nexpected\n%s", test.file, content, test.expected)
+		}
+		if migration.From != test.from || len(migration.Changes) != test.changes {
+			t.Errorf("%s: got migration from %s with changes %q, expected from %s with %d changes", test.file, migration.From, migration.Changes, test.from, test.changes)
+		}
+	}
+}
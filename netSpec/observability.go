@@ -0,0 +1,69 @@
+package netSpec
+
+import (
+	"errors"
+	"fmt"
+)
+
+//Constants used to identify metrics providers
+const (
+	MetricsProviderPrometheus string = "prometheus"
+	MetricsProviderStatsd     string = "statsd"
+	MetricsProviderDisabled   string = "disabled"
+)
+
+//ObservabilitySpec enables the operations endpoint of every peer and orderer and selects how metrics are published
+type ObservabilitySpec struct {
+	Metrics       string `yaml:"metrics"`
+	StatsdAddress string `yaml:"statsdAddress"`
+	//HostPort is the first host port allocated to operations endpoints, orderers first and then peers
+	HostPort        int    `yaml:"hostPort"`
+	Prometheus      bool   `yaml:"prometheus"`
+	PrometheusImage string `yaml:"prometheusImage"`
+	Grafana         bool   `yaml:"grafana"`
+	GrafanaImage    string `yaml:"grafanaImage"`
+}
+
+func (observability *ObservabilitySpec) setDefaults() {
+	if observability.Metrics == "" {
+		observability.Metrics = MetricsProviderPrometheus
+	}
+
+	if observability.HostPort == 0 {
+		observability.HostPort = 9443
+	}
+
+	if observability.PrometheusImage == "" {
+		observability.PrometheusImage = "prom/prometheus:v2.26.0"
+	}
+
+	if observability.GrafanaImage == "" {
+		observability.GrafanaImage = "grafana/grafana:7.5.4"
+	}
+}
+
+func (observability *ObservabilitySpec) validate(version *FabricVersion) error {
+	if version != nil && !version.AtLeast(1, 4, 0) {
+		return fmt.Errorf("Operations endpoints require Fabric 1.4.0 or later, images are %s", version)
+	}
+
+	switch observability.Metrics {
+	case MetricsProviderPrometheus, MetricsProviderDisabled:
+	case MetricsProviderStatsd:
+		if observability.StatsdAddress == "" {
+			return errors.New("StatsD address must be specified when metrics provider is 'statsd'")
+		}
+	default:
+		return fmt.Errorf("Unsupported metrics provider '%s', expected one of prometheus, statsd, disabled", observability.Metrics)
+	}
+
+	if observability.Prometheus && observability.Metrics != MetricsProviderPrometheus {
+		return fmt.Errorf("Prometheus service requires metrics provider 'prometheus', got '%s'", observability.Metrics)
+	}
+
+	if observability.Grafana && !observability.Prometheus {
+		return errors.New("Grafana service requires the Prometheus service to be enabled")
+	}
+
+	return nil
+}
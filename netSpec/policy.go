@@ -0,0 +1,247 @@
+package netSpec
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//Constants used to identify policy types
+const (
+	PolicyTypeSignature    string = "Signature"
+	PolicyTypeImplicitMeta string = "ImplicitMeta"
+)
+
+//PolicySpec is a policy as understood by configtxgen, e.g. Type: Signature, Rule: "OR('org1MSP.member')"
+type PolicySpec struct {
+	Type string `yaml:"type"`
+	Rule string `yaml:"rule"`
+}
+
+//PoliciesSpec holds the policies of every configuration level, indexed by policy name
+type PoliciesSpec struct {
+	Organizations map[string]map[string]*PolicySpec `yaml:"organizations"`
+	Channel       map[string]*PolicySpec            `yaml:"channel"`
+	Application   map[string]*PolicySpec            `yaml:"application"`
+}
+
+//Policies every level must define when policies are specified for it
+var requiredPolicies = []string{"Readers", "Writers", "Admins"}
+
+var principalRoles = map[string]bool{
+	"member":  true,
+	"admin":   true,
+	"client":  true,
+	"peer":    true,
+	"orderer": true,
+}
+
+var implicitMetaRuleRegexp = regexp.MustCompile(`^(ANY|ALL|MAJORITY) +([A-Za-z][A-Za-z0-9_-]*)$`)
+
+func (policies *PoliciesSpec) validate(spec *NetSpec) error {
+	mspIDs := make(map[string]bool)
+	for _, orgName := range spec.OrganizationNames() {
+		mspIDs[MSPID(orgName)] = true
+	}
+
+	for orgName, orgPolicies := range policies.Organizations {
+		if !mspIDs[MSPID(orgName)] {
+			return fmt.Errorf("Policies specified for unknown organization '%s'", orgName)
+		}
+
+		if err := validatePolicies(orgPolicies, mspIDs); err != nil {
+			return fmt.Errorf("Invalid policies for organization '%s': %v", orgName, err)
+		}
+	}
+
+	if err := validatePolicies(policies.Channel, mspIDs); err != nil {
+		return fmt.Errorf("Invalid channel policies: %v", err)
+	}
+
+	if err := validatePolicies(policies.Application, mspIDs); err != nil {
+		return fmt.Errorf("Invalid application policies: %v", err)
+	}
+
+	return nil
+}
+
+func validatePolicies(policies map[string]*PolicySpec, mspIDs map[string]bool) error {
+	if len(policies) == 0 {
+		return nil
+	}
+
+	for _, name := range requiredPolicies {
+		if policies[name] == nil {
+			return fmt.Errorf("policy '%s' must be specified", name)
+		}
+	}
+
+	for name, policy := range policies {
+		if err := policy.validate(mspIDs); err != nil {
+			return fmt.Errorf("policy '%s': %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (policy *PolicySpec) validate(mspIDs map[string]bool) error {
+	switch policy.Type {
+	case PolicyTypeImplicitMeta:
+		if !implicitMetaRuleRegexp.MatchString(policy.Rule) {
+			return fmt.Errorf("invalid ImplicitMeta rule '%s', expected '<ANY|ALL|MAJORITY> <SubPolicy>'", policy.Rule)
+		}
+	case PolicyTypeSignature:
+		p := &signatureRuleParser{rule: policy.Rule, mspIDs: mspIDs}
+		if err := p.parse(); err != nil {
+			return fmt.Errorf("invalid Signature rule '%s': %v", policy.Rule, err)
+		}
+	default:
+		return fmt.Errorf("unsupported policy type '%s'", policy.Type)
+	}
+
+	return nil
+}
+
+/* signatureRuleParser checks the syntax of signature policy rules as accepted by Fabric:
+ *   rule      := principal | OR(rule, ...) | AND(rule, ...) | OutOf(n, rule, ...)
+ *   principal := 'MSPID.role'
+ */
+type signatureRuleParser struct {
+	rule   string
+	pos    int
+	mspIDs map[string]bool
+}
+
+func (p *signatureRuleParser) parse() error {
+	if err := p.parseRule(); err != nil {
+		return err
+	}
+
+	p.skipSpaces()
+	if p.pos < len(p.rule) {
+		return fmt.Errorf("unexpected '%s' at position %d", p.rule[p.pos:], p.pos)
+	}
+
+	return nil
+}
+
+func (p *signatureRuleParser) parseRule() error {
+	p.skipSpaces()
+	if p.pos >= len(p.rule) {
+		return errors.New("unexpected end of rule")
+	}
+
+	if p.rule[p.pos] == '\'' {
+		return p.parsePrincipal()
+	}
+
+	start := p.pos
+	for p.pos < len(p.rule) && isLetter(p.rule[p.pos]) {
+		p.pos++
+	}
+	operator := p.rule[start:p.pos]
+
+	if operator != "OR" && operator != "AND" && operator != "OutOf" {
+		return fmt.Errorf("unknown operator '%s' at position %d", operator, start)
+	}
+
+	if err := p.expect('('); err != nil {
+		return err
+	}
+
+	args := 0
+	if operator == "OutOf" {
+		n, err := p.parseNumber()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(','); err != nil {
+			return err
+		}
+		if err := p.parseArgs(&args); err != nil {
+			return err
+		}
+		if n < 1 || n > args {
+			return fmt.Errorf("OutOf requires between 1 and %d signatures, got %d", args, n)
+		}
+		return nil
+	}
+
+	return p.parseArgs(&args)
+}
+
+func (p *signatureRuleParser) parseArgs(args *int) error {
+	for {
+		if err := p.parseRule(); err != nil {
+			return err
+		}
+		*args++
+
+		p.skipSpaces()
+		if p.pos < len(p.rule) && p.rule[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+
+		return p.expect(')')
+	}
+}
+
+func (p *signatureRuleParser) parsePrincipal() error {
+	start := p.pos
+	end := strings.IndexByte(p.rule[start+1:], '\'')
+	if end < 0 {
+		return fmt.Errorf("unterminated principal at position %d", start)
+	}
+	principal := p.rule[start+1 : start+1+end]
+	p.pos = start + end + 2
+
+	dot := strings.LastIndexByte(principal, '.')
+	if dot < 0 {
+		return fmt.Errorf("invalid principal '%s', expected 'MSPID.role'", principal)
+	}
+
+	mspID, role := principal[:dot], principal[dot+1:]
+	if !p.mspIDs[mspID] {
+		return fmt.Errorf("unknown MSP ID '%s'", mspID)
+	}
+	if !principalRoles[role] {
+		return fmt.Errorf("unknown role '%s' for MSP ID '%s'", role, mspID)
+	}
+
+	return nil
+}
+
+func (p *signatureRuleParser) parseNumber() (int, error) {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.rule) && p.rule[p.pos] >= '0' && p.rule[p.pos] <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, fmt.Errorf("expected number at position %d", start)
+	}
+	return strconv.Atoi(p.rule[start:p.pos])
+}
+
+func (p *signatureRuleParser) expect(c byte) error {
+	p.skipSpaces()
+	if p.pos >= len(p.rule) || p.rule[p.pos] != c {
+		return fmt.Errorf("expected '%c' at position %d", c, p.pos)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *signatureRuleParser) skipSpaces() {
+	for p.pos < len(p.rule) && p.rule[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,267 @@
+package netSpec
+
+import (
+	"reflect"
+	"strings"
+)
+
+//schemaAnnotation documents a spec field in the JSON Schema
+type schemaAnnotation struct {
+	Description string
+	Default     interface{}
+	Enum        []interface{}
+}
+
+//schemaAnnotations are indexed by type and field name, e.g. NetSpec.Network
+var schemaAnnotations = map[string]*schemaAnnotation{
+	"NetSpec.APIVersion":           {Description: "Spec format, netcomposer/v1 when missing, older formats are migrated when loaded", Enum: []interface{}{APIVersionV1, APIVersionV2}},
+	"NetSpec.DockerNS":             {Description: "Docker namespace of Fabric images, e.g. hyperledger"},
+	"NetSpec.FabricVersionTag":     {Description: "Version tag of Fabric images (peer, orderer, tools), e.g. 2.2.1"},
+	"NetSpec.CaVersionTag":         {Description: "Version tag of the Fabric CA image"},
+	"NetSpec.ThirdpartyVersionTag": {Description: "Version tag of CouchDB, Kafka and ZooKeeper images"},
+	"NetSpec.ExplorerVersionTag":   {Description: "Version tag of Hyperledger Explorer images", Default: "1.1.8"},
+	"NetSpec.ChannelCreationDelay": {Description: "Delay in seconds between starting the network and creating the channels"},
+	"NetSpec.Network":              {Description: "Name of the network, used as docker compose project and output directory"},
+	"NetSpec.Domain":               {Description: "Domain of the organizations, e.g. samplenet.com"},
+	"NetSpec.Description":          {Description: "Description of the network"},
+	"NetSpec.Orderer":              {Description: "Ordering service"},
+	"NetSpec.DB":                   {Description: "State database of the peers"},
+	"NetSpec.PeerOrgs":             {Description: "Number of peer organizations, named org1, org2..."},
+	"NetSpec.PeersPerOrg":          {Description: "Number of peers of every organization"},
+	"NetSpec.PeerOrgUsers":         {Description: "Number of users of every organization besides Admin, named User1, User2..., at least 1"},
+	"NetSpec.Channels":             {Description: "Application channels"},
+	"NetSpec.LogLevel":             {Description: "Deprecated since netcomposer/v2, use logging.spec"},
+	"NetSpec.TLSEnabled":           {Description: "Enables TLS on every node, required by etcdraft"},
+	"NetSpec.ChaincodesPath":       {Description: "Directory holding the chaincodes, environment variables are expanded"},
+	"NetSpec.Chaincodes":           {Description: "Chaincodes installed on the channels"},
+	"NetSpec.Policies":             {Description: "Policies of organizations, channels and applications"},
+	"NetSpec.Capabilities":         {Description: "Capabilities enabled at each configuration level, defaulted from the Fabric version"},
+	"NetSpec.ACLs":                 {Description: "Resource ACLs of every channel, indexed by resource, e.g. qscc/GetChainInfo: /Channel/Application/Readers"},
+	"NetSpec.Consortiums":          {Description: "Consortiums of the system channel, a single consortium with every organization by default"},
+	"NetSpec.Observability":        {Description: "Operations endpoints, metrics, Prometheus and Grafana"},
+	"NetSpec.Logging":              {Description: "FABRIC_LOGGING_SPEC of the network, organizations and nodes"},
+	"NetSpec.Explorer":             {Description: "Adds Hyperledger Explorer services", Default: false},
+	"NetSpec.ExplorerCredentials":  {Description: "Explorer login and database credentials"},
+	"NetSpec.Caliper":              {Description: "Generates a Hyperledger Caliper network config and starter benchmarks", Default: false},
+	"NetSpec.ConnectionProfiles":   {Description: "Connection profiles generated for every organization"},
+
+	"ExplorerCredentialsSpec.AdminUsername": {Description: "Explorer login user", Default: "exploreradmin"},
+	"ExplorerCredentialsSpec.AdminPassword": {Description: "Explorer login password", Default: "exploreradminpw"},
+	"ExplorerCredentialsSpec.DBUsername":    {Description: "User of the Explorer Postgres database", Default: "hppoc"},
+	"ExplorerCredentialsSpec.DBPassword":    {Description: "Password of the Explorer Postgres database", Default: "password"},
+
+	"ConnectionProfilesSpec.EmbedPEMs": {Description: "Inlines certificates in the profiles instead of referencing crypto-config files", Default: false},
+
+	"OrdererSpec.Type":                 {Description: "Ordering service type", Enum: []interface{}{OrderingServiceSOLO, OrderingServiceKafKa, OrderingServiceEtcdRaft}},
+	"OrdererSpec.Consenters":           {Description: "Number of orderers, when no orderer organization is specified", Default: 1},
+	"OrdererSpec.KafkaBrokers":         {Description: "Number of Kafka brokers, kafka ordering service only"},
+	"OrdererSpec.ZookeeperNodes":       {Description: "Number of ZooKeeper nodes, kafka ordering service only"},
+	"OrdererSpec.Batch":                {Description: "Block cutting parameters of every channel"},
+	"OrdererSpec.Organizations":        {Description: "Organizations running orderers, a single ordererOrg by default"},
+	"OrdererSpec.ChannelParticipation": {Description: "Starts orderers without system channel, requires etcdraft and Fabric 2.3+", Default: false},
+
+	"OrdererOrgSpec.Name":       {Description: "Name of the organization, its MSP ID is <name>MSP"},
+	"OrdererOrgSpec.Domain":     {Description: "Domain of the organization, <name>.<domain> by default"},
+	"OrdererOrgSpec.Consenters": {Description: "Number of orderers of the organization", Default: 1},
+	"OrdererOrgSpec.CA":         {Description: "Adds a CA for the organization", Default: false},
+
+	"BatchSpec.Timeout":           {Description: "Time to wait before cutting a block, e.g. 2s", Default: "2s"},
+	"BatchSpec.MaxMessageCount":   {Description: "Maximum number of transactions in a block", Default: 10},
+	"BatchSpec.AbsoluteMaxBytes":  {Description: "Absolute maximum size of a block, e.g. 99 MB", Default: "99 MB"},
+	"BatchSpec.PreferredMaxBytes": {Description: "Preferred maximum size of a block, e.g. 512 KB", Default: "512 KB"},
+
+	"ConsortiumSpec.Name":          {Description: "Name of the consortium"},
+	"ConsortiumSpec.Organizations": {Description: "IDs of the member peer organizations, every organization by default"},
+
+	"ChannelSpec.Name":          {Description: "Name of the channel"},
+	"ChannelSpec.Consortium":    {Description: "Consortium of the channel, the first consortium by default"},
+	"ChannelSpec.Organizations": {Description: "Member organizations, every organization of the consortium by default"},
+	"ChannelSpec.Batch":         {Description: "Block cutting parameters overriding the orderer ones"},
+	"ChannelSpec.ACLs":          {Description: "Resource ACLs of the channel, overriding the network ones"},
+
+	"ChannelOrgSpec.ID":    {Description: "ID of the peer organization, e.g. 1 for org1"},
+	"ChannelOrgSpec.Peers": {Description: "Peers joined to the channel, every peer as endorser by default"},
+
+	"ChannelPeerSpec.ID":             {Description: "ID of the peer in its organization, e.g. 1 for peer1"},
+	"ChannelPeerSpec.Endorser":       {Description: "Peer endorses transactions"},
+	"ChannelPeerSpec.QueryChaincode": {Description: "Peer accepts chaincode queries"},
+	"ChannelPeerSpec.QueryLedger":    {Description: "Peer accepts ledger queries"},
+	"ChannelPeerSpec.EventSource":    {Description: "Peer is an event source"},
+
+	"DBSpec.Provider":  {Description: "State database", Enum: []interface{}{DBProviderGoLevelDB, DBProviderCouchDB}},
+	"DBSpec.Port":      {Description: "CouchDB container port", Default: 5984},
+	"DBSpec.HostPort":  {Description: "First CouchDB port published on the host", Default: 5984},
+	"DBSpec.Namespace": {Description: "Docker namespace of the CouchDB image"},
+	"DBSpec.Image":     {Description: "CouchDB image"},
+	"DBSpec.Username":  {Description: "CouchDB admin user", Default: "admin"},
+	"DBSpec.Password":  {Description: "CouchDB admin password, random by default"},
+	"DBSpec.Driver":    {Description: "Database driver"},
+	"DBSpec.DB":        {Description: "Database name"},
+
+	"ChaincodeSpec.Name":           {Description: "Name of the chaincode"},
+	"ChaincodeSpec.Channels":       {Description: "Channels the chaincode is instantiated on"},
+	"ChaincodeSpec.Language":       {Description: "Chaincode language", Enum: []interface{}{"golang", "node", "java"}},
+	"ChaincodeSpec.Path":           {Description: "Path of the chaincode relative to chaincodesPath"},
+	"ChaincodeSpec.Version":        {Description: "Version of the chaincode"},
+	"ChaincodeSpec.Indexes":        {Description: "CouchDB indexes packaged with the chaincode"},
+	"ChaincodeSpec.Benchmark":      {Description: "Starter Caliper benchmark, query function by default"},
+	"ChaincodeSpec.EndorcingRules": {Description: "Endorsement policy terms"},
+
+	"ChaincodeIndexSpec.Name":      {Description: "Name of the index"},
+	"ChaincodeIndexSpec.DesignDoc": {Description: "Design document of the index, <name>Doc by default"},
+	"ChaincodeIndexSpec.Fields":    {Description: "Indexed fields"},
+
+	"EndorcingRuleTermSpec.Organization": {Description: "Organization whose peers endorse"},
+	"EndorcingRuleTermSpec.Endorsements": {Description: "Number of endorsements required from the organization"},
+
+	"ChaincodeBenchmarkSpec.Function": {Description: "Chaincode function invoked by the benchmark", Default: "query"},
+	"ChaincodeBenchmarkSpec.Args":     {Description: "Arguments of the function, [a] for the default query benchmark"},
+	"ChaincodeBenchmarkSpec.ReadOnly": {Description: "Evaluates transactions instead of submitting them for ordering", Default: true},
+	"ChaincodeBenchmarkSpec.Workers":  {Description: "Number of Caliper workers", Default: 1},
+	"ChaincodeBenchmarkSpec.TxNumber": {Description: "Number of transactions of the round", Default: 100},
+	"ChaincodeBenchmarkSpec.TPS":      {Description: "Transactions per second sent by the workers", Default: 10},
+	"ChaincodeBenchmarkSpec.InitArgs": {Description: "Function and arguments the chaincode is instantiated with by provision.sh", Default: []interface{}{"init", "a", "100", "b", "200"}},
+
+	"PolicySpec.Type": {Description: "Policy type", Enum: []interface{}{PolicyTypeSignature, PolicyTypeImplicitMeta}},
+	"PolicySpec.Rule": {Description: "Policy rule, e.g. OR('org1MSP.member') or ANY Readers"},
+
+	"PoliciesSpec.Organizations": {Description: "Policies of organizations, indexed by organization name and policy name"},
+	"PoliciesSpec.Channel":       {Description: "Channel policies, indexed by policy name"},
+	"PoliciesSpec.Application":   {Description: "Application policies, indexed by policy name"},
+
+	"CapabilitiesSpec.Channel":     {Description: "Channel capability, e.g. V2_0"},
+	"CapabilitiesSpec.Orderer":     {Description: "Orderer capability, e.g. V2_0"},
+	"CapabilitiesSpec.Application": {Description: "Application capability, e.g. V1_4_2, V2_0 and later require deploying chaincodes with peer lifecycle"},
+
+	"ObservabilitySpec.Metrics":         {Description: "Metrics provider", Default: MetricsProviderPrometheus, Enum: []interface{}{MetricsProviderPrometheus, MetricsProviderStatsd, MetricsProviderDisabled}},
+	"ObservabilitySpec.StatsdAddress":   {Description: "StatsD address, statsd provider only"},
+	"ObservabilitySpec.HostPort":        {Description: "First host port of operations endpoints, orderers first and then peers", Default: 9443},
+	"ObservabilitySpec.Prometheus":      {Description: "Adds a Prometheus service scraping every node", Default: false},
+	"ObservabilitySpec.PrometheusImage": {Description: "Prometheus image", Default: "prom/prometheus:v2.26.0"},
+	"ObservabilitySpec.Grafana":         {Description: "Adds a Grafana service using Prometheus as datasource", Default: false},
+	"ObservabilitySpec.GrafanaImage":    {Description: "Grafana image", Default: "grafana/grafana:7.5.4"},
+
+	"LoggingSpec.Spec":          {Description: "Logging spec of the network, info by default"},
+	"LoggingSpec.Organizations": {Description: "Logging specs indexed by organization name, e.g. org1 or ordererOrg"},
+	"LoggingSpec.Nodes":         {Description: "Logging specs indexed by container name, e.g. peer1.org1.samplenet.com"},
+}
+
+//variablePattern allows ${VAR} references in values that are not strings
+const variablePattern = `\$\{[A-Za-z_][A-Za-z0-9_]*(:-[^}]*)?\}`
+
+//JSONSchema returns the JSON Schema (draft-07) of spec files, derived from the NetSpec types
+func JSONSchema() map[string]interface{} {
+	definitions := make(map[string]interface{})
+	root := structSchema(reflect.TypeOf(NetSpec{}), definitions)
+
+	properties := root["properties"].(map[string]interface{})
+	properties[extendsKey] = stringOrList("Base spec files, relative to this file")
+	properties[includeKey] = stringOrList("Spec files merged after the extended ones, relative to this file")
+
+	root["$schema"] = "http://json-schema.org/draft-07/schema#"
+	root["title"] = "netcomposer spec"
+	root["definitions"] = definitions
+	return root
+}
+
+func stringOrList(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+	}
+}
+
+//structSchema returns the schema of a struct, properties are named after the yaml field names
+func structSchema(structType reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name := yamlFieldName(field)
+		if name == "" {
+			continue
+		}
+
+		property := typeSchema(field.Type, definitions)
+		if annotation, found := schemaAnnotations[structType.Name()+"."+field.Name]; found {
+			//Referenced definitions can't be annotated directly in draft-07
+			if _, ref := property["$ref"]; ref {
+				property = map[string]interface{}{"allOf": []interface{}{property}}
+			}
+			if annotation.Description != "" {
+				property["description"] = annotation.Description
+			}
+			if annotation.Default != nil {
+				property["default"] = annotation.Default
+			}
+			if annotation.Enum != nil {
+				property["anyOf"] = []interface{}{
+					map[string]interface{}{"enum": annotation.Enum},
+					map[string]interface{}{"type": "string", "pattern": variablePattern},
+				}
+				delete(property, "type")
+			}
+		}
+		properties[name] = property
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+//typeSchema returns the schema of a type, structs are defined once and referenced
+func typeSchema(fieldType reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	switch fieldType.Kind() {
+	case reflect.Ptr:
+		return typeSchema(fieldType.Elem(), definitions)
+	case reflect.Struct:
+		name := fieldType.Name()
+		if _, defined := definitions[name]; !defined {
+			definitions[name] = map[string]interface{}{}
+			definitions[name] = structSchema(fieldType, definitions)
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + name}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(fieldType.Elem(), definitions)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(fieldType.Elem(), definitions)}
+	case reflect.Bool:
+		return variableOr("boolean")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return variableOr("integer")
+	}
+	//Unquoted numbers such as version: 1.0 are decoded as strings
+	return map[string]interface{}{"type": []interface{}{"string", "number"}}
+}
+
+//variableOr accepts a value of the type or a ${VAR} reference interpolated when loading the spec
+func variableOr(jsonType string) map[string]interface{} {
+	return map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": jsonType},
+			map[string]interface{}{"type": "string", "pattern": variablePattern},
+		},
+	}
+}
+
+//yamlFieldName returns the name of a field in spec files, as yaml.v2 does: the tag name or the lowercased field name
+func yamlFieldName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+
+	tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if tag == "-" {
+		return ""
+	}
+	if tag != "" {
+		return tag
+	}
+	return strings.ToLower(field.Name)
+}
@@ -0,0 +1,104 @@
+package netSpec
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+//TestSchemaAnnotationsResolve checks every annotation documents a field of a spec type
+func TestSchemaAnnotationsResolve(t *testing.T) {
+	fields := make(map[string]bool)
+	collectSpecFields(reflect.TypeOf(NetSpec{}), fields)
+
+	for key := range schemaAnnotations {
+		if !fields[key] {
+			t.Errorf("Annotation '%s' does not match any field of the spec types", key)
+		}
+	}
+}
+
+//TestSchemaAnnotationDefaults checks annotated defaults are the values set by SetDefaults
+func TestSchemaAnnotationDefaults(t *testing.T) {
+	//Optional sections are enabled so that SetDefaults fills them
+	spec := &NetSpec{
+		FabricVersionTag: "2.2.1",
+		Network:          "testnet",
+		Domain:           "testnet.com",
+		PeerOrgs:         1,
+		PeersPerOrg:      1,
+		Orderer:          &OrdererSpec{Type: OrderingServiceSOLO, Organizations: []*OrdererOrgSpec{{Name: "ordererOrg"}}},
+		DB:               &DBSpec{Provider: DBProviderCouchDB},
+		Channels:         []*ChannelSpec{{Name: "testchannel"}},
+		Chaincodes:       []*ChaincodeSpec{{Name: "kv", Channels: []string{"testchannel"}}},
+		Observability:    &ObservabilitySpec{},
+		Explorer:         true,
+		Caliper:          true,
+	}
+	spec.SetDefaults()
+
+	checked := make(map[string]bool)
+	checkSpecDefaults(t, reflect.ValueOf(spec), checked)
+
+	for key, annotation := range schemaAnnotations {
+		if annotation.Default != nil && !checked[key] {
+			t.Errorf("Default of '%s' is not checked, the field is not set by the test spec", key)
+		}
+	}
+}
+
+func collectSpecFields(structType reflect.Type, fields map[string]bool) {
+	for structType.Kind() == reflect.Ptr || structType.Kind() == reflect.Slice || structType.Kind() == reflect.Map {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct || fields[structType.Name()] {
+		return
+	}
+	fields[structType.Name()] = true
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if yamlFieldName(field) == "" {
+			continue
+		}
+		fields[structType.Name()+"."+field.Name] = true
+		collectSpecFields(field.Type, fields)
+	}
+}
+
+//checkSpecDefaults compares the annotated defaults with the fields of a defaulted spec, defaults which are zero values
+//are left as decoded and not compared
+func checkSpecDefaults(t *testing.T, value reflect.Value, checked map[string]bool) {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if !value.IsNil() {
+			checkSpecDefaults(t, value.Elem(), checked)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			checkSpecDefaults(t, value.Index(i), checked)
+		}
+	case reflect.Map:
+		for _, key := range value.MapKeys() {
+			checkSpecDefaults(t, value.MapIndex(key), checked)
+		}
+	case reflect.Struct:
+		structType := value.Type()
+		for i := 0; i < structType.NumField(); i++ {
+			field := structType.Field(i)
+			if yamlFieldName(field) == "" {
+				continue
+			}
+
+			key := structType.Name() + "." + field.Name
+			if annotation, found := schemaAnnotations[key]; found && annotation.Default != nil {
+				expected := fmt.Sprint(annotation.Default)
+				if expected != fmt.Sprint(reflect.Zero(field.Type).Interface()) && expected != fmt.Sprint(value.Field(i).Interface()) {
+					t.Errorf("Annotated default of '%s' is %s, SetDefaults sets %v", key, expected, value.Field(i).Interface())
+				}
+				checked[key] = true
+			}
+			checkSpecDefaults(t, value.Field(i), checked)
+		}
+	}
+}
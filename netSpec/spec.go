@@ -1,12 +1,15 @@
 package netSpec
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
-
-	yaml "gopkg.in/yaml.v2"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 )
 
 //Constants used to identify DBProvider and Ordering Service
@@ -14,15 +17,21 @@ const (
 	DBProviderGoLevelDB string = "goleveldb"
 	DBProviderCouchDB   string = "CouchDB"
 
-	OrderingServiceSOLO  string = "solo"
-	OrderingServiceKafKa string = "kafka"
+	OrderingServiceSOLO     string = "solo"
+	OrderingServiceKafKa    string = "kafka"
+	OrderingServiceEtcdRaft string = "etcdraft"
+
+	OrdererOrgName string = "ordererOrg"
 )
 
 type NetSpec struct {
+	//APIVersion is the spec format, spec files in older formats are migrated when loaded
+	APIVersion           string           `yaml:"apiVersion"`
 	DockerNS             string           `yaml:"DOCKER_NS"`
 	FabricVersionTag     string           `yaml:"FABRIC_VERSION_TAG"`
 	CaVersionTag         string           `yaml:"CA_VERSION_TAG"`
 	ThirdpartyVersionTag string           `yaml:"THIRDPARTY_VERSION_TAG"`
+	ExplorerVersionTag   string           `yaml:"EXPLORER_VERSION_TAG"`
 	ChannelCreationDelay int			  `yaml:"CHANNEL_CREATION_DELAY"`
 	Network              string           `yaml:"network"`
 	Domain               string           `yaml:"domain"`
@@ -33,22 +42,91 @@ type NetSpec struct {
 	PeersPerOrg          int              `yaml:"peersPerOrganization"`
 	PeerOrgUsers         int              `yaml:"usersPerOrganization"`
 	Channels             []*ChannelSpec   `yaml:"channels"`
+	//LogLevel is deprecated since netcomposer/v2, replaced by Logging.Spec
 	LogLevel             string           `yaml:"logLevel"`
 	TLSEnabled           bool             `yaml:"tlsEnabled"`
 	ChaincodesPath       string           `yaml:"chaincodesPath"`
 	Chaincodes           []*ChaincodeSpec `yaml:"chaincodes"`
+	Policies             *PoliciesSpec     `yaml:"policies"`
+	Capabilities         *CapabilitiesSpec `yaml:"capabilities"`
+	ACLs                 map[string]string `yaml:"acls"`
+	Consortiums          []*ConsortiumSpec `yaml:"consortiums"`
+	Observability        *ObservabilitySpec `yaml:"observability"`
+	Logging              *LoggingSpec       `yaml:"logging"`
+	Explorer             bool               `yaml:"explorer"`
+	ExplorerCredentials  *ExplorerCredentialsSpec `yaml:"explorerCredentials"`
+	Caliper              bool               `yaml:"caliper"`
+	ConnectionProfiles   *ConnectionProfilesSpec `yaml:"connectionProfiles"`
+}
+
+//ConnectionProfilesSpec controls the connection profiles generated for each organization
+type ConnectionProfilesSpec struct {
+	//EmbedPEMs inlines certificates in the profiles instead of referencing crypto-config files
+	EmbedPEMs bool `yaml:"embedPEMs"`
+}
+
+//ExplorerCredentialsSpec holds the Explorer login and the credentials of its Postgres database
+type ExplorerCredentialsSpec struct {
+	AdminUsername string `yaml:"adminUsername"`
+	AdminPassword string `yaml:"adminPassword"`
+	DBUsername    string `yaml:"dbUsername"`
+	DBPassword    string `yaml:"dbPassword"`
+}
+
+func (credentials *ExplorerCredentialsSpec) setDefaults() {
+	if credentials.AdminUsername == "" {
+		credentials.AdminUsername = "exploreradmin"
+	}
+	if credentials.AdminPassword == "" {
+		credentials.AdminPassword = "exploreradminpw"
+	}
+	if credentials.DBUsername == "" {
+		credentials.DBUsername = "hppoc"
+	}
+	if credentials.DBPassword == "" {
+		credentials.DBPassword = "password"
+	}
 }
 
 type OrdererSpec struct {
-	Type           string `yaml:"type"`
-	Consenters     int    `yaml:"consenters"`
-	KafkaBrokers   int    `yaml:"kafkaBrokers"`
-	ZookeeperNodes int    `yaml:"zookeeperNodes"`
+	Type           string            `yaml:"type"`
+	Consenters     int               `yaml:"consenters"`
+	KafkaBrokers   int               `yaml:"kafkaBrokers"`
+	ZookeeperNodes int               `yaml:"zookeeperNodes"`
+	Batch          *BatchSpec        `yaml:"batch"`
+	Organizations  []*OrdererOrgSpec `yaml:"organizations"`
+	//ChannelParticipation starts orderers without system channel, they are joined to channels through their admin endpoint
+	ChannelParticipation bool `yaml:"channelParticipation"`
+}
+
+//OrdererOrgSpec is an organization running ordering nodes
+type OrdererOrgSpec struct {
+	Name       string `yaml:"name"`
+	Domain     string `yaml:"domain"`
+	Consenters int    `yaml:"consenters"`
+	CA         bool   `yaml:"ca"`
+}
+
+//BatchSpec controls how the ordering service cuts blocks
+type BatchSpec struct {
+	Timeout           string `yaml:"timeout"`
+	MaxMessageCount   int    `yaml:"maxMessageCount"`
+	AbsoluteMaxBytes  string `yaml:"absoluteMaxBytes"`
+	PreferredMaxBytes string `yaml:"preferredMaxBytes"`
+}
+
+//ConsortiumSpec groups the peer organizations (by ID) allowed to create channels together
+type ConsortiumSpec struct {
+	Name          string `yaml:"name"`
+	Organizations []int  `yaml:"organizations"`
 }
 
 type ChannelSpec struct {
 	Name          string            `yaml:"name"`
+	Consortium    string            `yaml:"consortium"`
 	Organizations []*ChannelOrgSpec `yaml:"organizations"`
+	Batch         *BatchSpec        `yaml:"batch"`
+	ACLs          map[string]string `yaml:"acls"`
 }
 
 type ChannelOrgSpec struct {
@@ -79,12 +157,21 @@ type DBSpec struct {
 type ChaincodeSpec struct {
 	Name           string `yaml:"name"`
 	Channels       []string
-	Language       string `yaml:"language"`
-	Path           string `yaml:"path"`
-	Version        string `yaml:"version"`
+	Language       string                  `yaml:"language"`
+	Path           string                  `yaml:"path"`
+	Version        string                  `yaml:"version"`
+	Indexes        []*ChaincodeIndexSpec   `yaml:"indexes"`
+	Benchmark      *ChaincodeBenchmarkSpec `yaml:"benchmark"`
 	EndorcingRules []*EndorcingRuleSpec
 }
 
+//ChaincodeIndexSpec declares a CouchDB index packaged with the chaincode
+type ChaincodeIndexSpec struct {
+	Name      string   `yaml:"name"`
+	DesignDoc string   `yaml:"ddoc"`
+	Fields    []string `yaml:"fields"`
+}
+
 type EndorcingRuleSpec struct {
 	Terms []*EndorcingRuleTermSpec
 }
@@ -94,20 +181,9 @@ type EndorcingRuleTermSpec struct {
 	Endorsements int    `yaml:"endorsements"`
 }
 
+//LoadFromFile loads a spec file, composed with the specs it extends or includes, with variables interpolated
 func LoadFromFile(specFile string) (*NetSpec, error) {
-	yamlFile, err := ioutil.ReadFile(specFile)
-	if err != nil {
-		log.Printf("Error reading net specification file:   #%v ", err)
-		return nil, err
-	}
-
-	spec := &NetSpec{}
-	err = yaml.Unmarshal(yamlFile, spec)
-	if err != nil {
-		log.Fatalf("Unmarshal: %v", err)
-		return nil, err
-	}
-	return spec, nil
+	return LoadFromFiles(specFile, nil, nil)
 }
 
 func (spec *NetSpec) SetDefaults() {
@@ -118,6 +194,72 @@ func (spec *NetSpec) SetDefaults() {
 		spec.Orderer.Consenters = 1
 	}
 
+	//DEFAULT: a single orderer organization running every consenter
+	if len(spec.Orderer.Organizations) == 0 {
+		spec.Orderer.Organizations = []*OrdererOrgSpec{
+			{Name: OrdererOrgName, Domain: spec.Domain, Consenters: spec.Orderer.Consenters},
+		}
+	}
+
+	consenters := 0
+	for _, ordOrgSpec := range spec.Orderer.Organizations {
+		if ordOrgSpec.Domain == "" {
+			ordOrgSpec.Domain = fmt.Sprintf("%s.%s", strings.ToLower(ordOrgSpec.Name), spec.Domain)
+		}
+		if ordOrgSpec.Consenters < 1 {
+			ordOrgSpec.Consenters = 1
+		}
+		consenters += ordOrgSpec.Consenters
+	}
+	// Consenters is the total number of orderer nodes among all orderer organizations
+	spec.Orderer.Consenters = consenters
+
+	//DEFAULT: batch values used by configtxgen sample profiles
+	if spec.Orderer.Batch == nil {
+		spec.Orderer.Batch = &BatchSpec{}
+	}
+	spec.Orderer.Batch.setDefaults(&BatchSpec{
+		Timeout:           "2s",
+		MaxMessageCount:   10,
+		AbsoluteMaxBytes:  "99 MB",
+		PreferredMaxBytes: "512 KB",
+	})
+
+	if spec.Policies == nil {
+		spec.Policies = &PoliciesSpec{}
+	}
+
+	if spec.Capabilities == nil {
+		spec.Capabilities = &CapabilitiesSpec{}
+	}
+	spec.Capabilities.setDefaults(spec.FabricVersion())
+
+	if spec.Observability != nil {
+		spec.Observability.setDefaults()
+	}
+
+	//DEFAULT: Explorer release supporting Fabric 1.4 to 2.x
+	if spec.Explorer && spec.ExplorerVersionTag == "" {
+		spec.ExplorerVersionTag = "1.1.8"
+	}
+
+	//DEFAULT: credentials of the Explorer samples
+	if spec.Explorer {
+		if spec.ExplorerCredentials == nil {
+			spec.ExplorerCredentials = &ExplorerCredentialsSpec{}
+		}
+		spec.ExplorerCredentials.setDefaults()
+	}
+
+	if spec.ConnectionProfiles == nil {
+		spec.ConnectionProfiles = &ConnectionProfilesSpec{}
+	}
+
+	if spec.Logging == nil {
+		spec.Logging = &LoggingSpec{}
+	}
+	spec.Logging.setDefaults(spec.LogLevel)
+
 	// Set default ports for CouchDB when not specified in config file
 	if spec.DB.Provider == DBProviderCouchDB {
 		if spec.DB.Port == 0 {
@@ -126,14 +268,64 @@ func (spec *NetSpec) SetDefaults() {
 		if spec.DB.HostPort == 0 {
 			spec.DB.HostPort = 5984
 		}
+		// CouchDB admin credentials are shared by the database containers and their peers
+		if spec.DB.Username == "" {
+			spec.DB.Username = "admin"
+		}
+		if spec.DB.Password == "" {
+			spec.DB.Password = randomPassword()
+		}
+	}
+
+	for _, ccSpec := range spec.Chaincodes {
+		for _, idxSpec := range ccSpec.Indexes {
+			//DEFAULT: one design document per index
+			if idxSpec.DesignDoc == "" {
+				idxSpec.DesignDoc = idxSpec.Name + "Doc"
+			}
+		}
+
+		//DEFAULT: starter benchmark evaluating the query function
+		if spec.Caliper {
+			if ccSpec.Benchmark == nil {
+				ccSpec.Benchmark = &ChaincodeBenchmarkSpec{Function: "query", Args: []string{"a"}, ReadOnly: true}
+			}
+			ccSpec.Benchmark.setDefaults()
+		}
+	}
+
+	//DEFAULT: a single consortium with every peer organization
+	if len(spec.Consortiums) == 0 {
+		spec.Consortiums = []*ConsortiumSpec{{Name: spec.Network + "Consortium"}}
+	}
+
+	for _, consortiumSpec := range spec.Consortiums {
+		if len(consortiumSpec.Organizations) == 0 {
+			consortiumSpec.Organizations = make([]int, spec.PeerOrgs)
+			for i := 0; i < spec.PeerOrgs; i++ {
+				consortiumSpec.Organizations[i] = i + 1
+			}
+		}
 	}
 
 	for _, chSpec := range spec.Channels {
-		//DEFAULT: when no organizations are specified for the channel, it means all organizations
+		//DEFAULT: channels belong to the first consortium
+		if chSpec.Consortium == "" {
+			chSpec.Consortium = spec.Consortiums[0].Name
+		}
+
+		//DEFAULT: channel batch values not overridden are inherited from the orderer
+		if chSpec.Batch != nil {
+			chSpec.Batch.setDefaults(spec.Orderer.Batch)
+		}
+
+		//DEFAULT: when no organizations are specified for the channel, it means all organizations of its consortium
 		if chSpec.Organizations == nil || len(chSpec.Organizations) == 0 {
-			chSpec.Organizations = make([]*ChannelOrgSpec, spec.PeerOrgs)
-			for i := 0; i < spec.PeerOrgs; i++ {
-				chSpec.Organizations[i] = &ChannelOrgSpec{ID: i + 1}
+			if consortiumSpec := spec.consortium(chSpec.Consortium); consortiumSpec != nil {
+				chSpec.Organizations = make([]*ChannelOrgSpec, len(consortiumSpec.Organizations))
+				for i, orgID := range consortiumSpec.Organizations {
+					chSpec.Organizations[i] = &ChannelOrgSpec{ID: orgID}
+				}
 			}
 		}
 
@@ -157,6 +349,10 @@ func (spec *NetSpec) SetDefaults() {
 }
 
 func (spec *NetSpec) Validate() error {
+	if spec.APIVersion != "" && spec.APIVersion != APIVersion {
+		return fmt.Errorf("Unsupported apiVersion '%s', spec files must be migrated to %s when loaded", spec.APIVersion, APIVersion)
+	}
+
 	if spec.DockerNS == "" {
 		return errors.New("DOCKER_NS must be specified")
 	}
@@ -173,10 +369,38 @@ func (spec *NetSpec) Validate() error {
 		return errors.New("THIRDPARTY_VERSION_TAG must be specified")
 	}
 
-	if spec.Orderer.Type != OrderingServiceSOLO && spec.Orderer.Type != OrderingServiceKafKa {
+	if spec.Orderer.Type != OrderingServiceSOLO && spec.Orderer.Type != OrderingServiceKafKa && spec.Orderer.Type != OrderingServiceEtcdRaft {
 		return fmt.Errorf("Unsupported orderer type '%s'", spec.Orderer.Type)
 	}
 
+	if spec.Orderer.ChannelParticipation {
+		if spec.Orderer.Type != OrderingServiceEtcdRaft {
+			return fmt.Errorf("Channel participation requires orderer type '%s', got '%s'", OrderingServiceEtcdRaft, spec.Orderer.Type)
+		}
+
+		if version := spec.FabricVersion(); version != nil && !version.AtLeast(2, 3, 0) {
+			return fmt.Errorf("Channel participation requires Fabric 2.3.0 or later, images are %s", version)
+		}
+	}
+
+	if spec.Orderer.Type == OrderingServiceEtcdRaft {
+		if !spec.TLSEnabled {
+			return fmt.Errorf("TLS must be enabled if orderer type is '%s'", spec.Orderer.Type)
+		}
+
+		if version := spec.FabricVersion(); version != nil && !version.AtLeast(1, 4, 2) {
+			return fmt.Errorf("Orderer type '%s' requires Fabric 1.4.2 or later, the first release with orderer capability V1_4_2, images are %s", spec.Orderer.Type, version)
+		}
+
+		if spec.Capabilities.Orderer != "" && !capabilityAtLeast(ordererCapabilities, spec.Capabilities.Orderer, "V1_4_2") {
+			return fmt.Errorf("Orderer type '%s' requires orderer capability V1_4_2 or later, got '%s'", spec.Orderer.Type, spec.Capabilities.Orderer)
+		}
+	}
+
+	if err := spec.validateOrdererOrgs(); err != nil {
+		return err
+	}
+
 	if spec.Orderer.Type == OrderingServiceKafKa && spec.Orderer.Consenters <= 0 {
 		return fmt.Errorf("A positive number of orderer nodes (consenters) is required if orderer type is '%s'", spec.Orderer.Type)
 	}
@@ -189,6 +413,10 @@ func (spec *NetSpec) Validate() error {
 		return fmt.Errorf("A positive number of zookeeper nodes is required if orderer type is '%s'", spec.Orderer.Type)
 	}
 
+	if err := spec.Orderer.Batch.validate(); err != nil {
+		return fmt.Errorf("Invalid orderer batch: %v", err)
+	}
+
 	if spec.DB.Provider != DBProviderGoLevelDB && spec.DB.Provider != DBProviderCouchDB {
 		log.Printf("Warnning: using unofficial db provider  '%s'\r\n", spec.DB.Provider)
 	}
@@ -197,20 +425,44 @@ func (spec *NetSpec) Validate() error {
 		return errors.New("Number of peer organization must be greater than 0")
 	}
 
-	if spec.PeerOrgUsers < 0 {
-		return errors.New("Number of user peers per organization must be non negative")
+	//Caliper and client identities use User1 of every organization
+	if spec.PeerOrgUsers <= 0 {
+		return errors.New("Number of users per organization must be greater than 0")
+	}
+
+	if err := spec.validateConsortiums(); err != nil {
+		return err
 	}
 
 	for _, chSpec := range spec.Channels {
+		consortiumSpec := spec.consortium(chSpec.Consortium)
+		if consortiumSpec == nil {
+			return fmt.Errorf("Channel '%s' references unknown consortium '%s'", chSpec.Name, chSpec.Consortium)
+		}
+
 		if chSpec.Organizations == nil || len(chSpec.Organizations) == 0 {
 			return fmt.Errorf("Channel '%s' has not specified any organization", chSpec.Name)
 		}
 
+		if chSpec.Batch != nil {
+			if err := chSpec.Batch.validate(); err != nil {
+				return fmt.Errorf("Invalid batch for channel '%s': %v", chSpec.Name, err)
+			}
+		}
+
+		if err := validateACLs(chSpec.ACLs, spec.FabricVersion(), spec.Capabilities); err != nil {
+			return fmt.Errorf("Invalid ACLs for channel '%s': %v", chSpec.Name, err)
+		}
+
 		for _, chOrgSpec := range chSpec.Organizations {
 			if chOrgSpec.ID < 1 || chOrgSpec.ID > spec.PeerOrgs {
 				return fmt.Errorf("Invalid organization ID '%d' specified for channel '%s'", chOrgSpec.ID, chSpec.Name)
 			}
 
+			if !consortiumSpec.hasOrganization(chOrgSpec.ID) {
+				return fmt.Errorf("Organization '%d' of channel '%s' is not a member of consortium '%s'", chOrgSpec.ID, chSpec.Name, consortiumSpec.Name)
+			}
+
 			if chOrgSpec.Peers == nil || len(chOrgSpec.Peers) == 0 {
 				return fmt.Errorf("Channel '%s' has not specified any peer for organization '%d'", chSpec.Name, chOrgSpec.ID)
 			}
@@ -223,7 +475,276 @@ func (spec *NetSpec) Validate() error {
 		}
 	}
 
-	//TODO: validate chaincodes, including references to nonexistent channels
+	if err := spec.Policies.validate(spec); err != nil {
+		return err
+	}
+
+	if err := spec.Capabilities.validate(spec.FabricVersion()); err != nil {
+		return err
+	}
+
+	if spec.Observability != nil {
+		if err := spec.Observability.validate(spec.FabricVersion()); err != nil {
+			return err
+		}
+	}
+
+	if spec.LogLevel != "" {
+		log.Printf("Warning: logLevel is deprecated since %s, use logging.spec\r\n", APIVersionV2)
+	}
+
+	if err := spec.Logging.validate(spec); err != nil {
+		return err
+	}
+
+	if spec.Explorer {
+		if version := spec.FabricVersion(); version != nil && !version.AtLeast(1, 4, 0) {
+			return fmt.Errorf("Hyperledger Explorer requires Fabric 1.4.0 or later, images are %s", version)
+		}
+	}
+
+	if spec.Caliper {
+		if version := spec.FabricVersion(); version != nil && !version.AtLeast(1, 4, 0) {
+			return fmt.Errorf("Hyperledger Caliper requires Fabric 1.4.0 or later, images are %s", version)
+		}
+
+		//provision.sh instantiates the chaincodes benchmarked by Caliper with the legacy lifecycle
+		if capabilityAtLeast(applicationCapabilities, spec.Capabilities.Application, lifecycleCapability) {
+			return fmt.Errorf("Hyperledger Caliper requires application capability %s or earlier, chaincodes are instantiated with the legacy lifecycle, got '%s'", legacyLifecycleCapability, spec.Capabilities.Application)
+		}
+	}
+
+	if err := validateACLs(spec.ACLs, spec.FabricVersion(), spec.Capabilities); err != nil {
+		return fmt.Errorf("Invalid ACLs: %v", err)
+	}
+
+	if spec.Policies.Application["LifecycleEndorsement"] != nil && !capabilityAtLeast(applicationCapabilities, spec.Capabilities.Application, "V2_0") {
+		log.Printf("Warning: LifecycleEndorsement policy is only used with application capability V2_0 or later\r\n")
+	}
+
+	for _, ccSpec := range spec.Chaincodes {
+		if len(ccSpec.Indexes) > 0 && spec.DB.Provider != DBProviderCouchDB {
+			log.Printf("Warning: indexes of chaincode '%s' are only used with db provider '%s'\r\n", ccSpec.Name, DBProviderCouchDB)
+		}
+
+		indexNames := make(map[string]bool, len(ccSpec.Indexes))
+		for _, idxSpec := range ccSpec.Indexes {
+			if idxSpec.Name == "" {
+				return fmt.Errorf("Chaincode '%s' has an index without name", ccSpec.Name)
+			}
+
+			if indexNames[idxSpec.Name] {
+				return fmt.Errorf("Index '%s' is specified more than once for chaincode '%s'", idxSpec.Name, ccSpec.Name)
+			}
+			indexNames[idxSpec.Name] = true
+
+			if len(idxSpec.Fields) == 0 {
+				return fmt.Errorf("Index '%s' of chaincode '%s' has not specified any field", idxSpec.Name, ccSpec.Name)
+			}
+		}
+
+		if ccSpec.Benchmark != nil {
+			if !spec.Caliper {
+				log.Printf("Warning: benchmark of chaincode '%s' is only used when caliper is enabled\r\n", ccSpec.Name)
+			} else if err := ccSpec.Benchmark.validate(ccSpec.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	channelNames := make(map[string]bool, len(spec.Channels))
+	for _, chSpec := range spec.Channels {
+		channelNames[chSpec.Name] = true
+	}
+	for _, ccSpec := range spec.Chaincodes {
+		for _, chName := range ccSpec.Channels {
+			if !channelNames[chName] {
+				return fmt.Errorf("Chaincode '%s' references unknown channel '%s'", ccSpec.Name, chName)
+			}
+		}
+	}
+
+	return nil
+}
+
+//PeerOrgName returns the name of the peer organization with the given ID (starting at 1)
+func PeerOrgName(id int) string {
+	return fmt.Sprintf("org%d", id)
+}
+
+//MSPID returns the MSP ID of an organization
+func MSPID(orgName string) string {
+	return orgName + "MSP"
+}
+
+func (spec *NetSpec) validateOrdererOrgs() error {
+	peerOrgNames := make(map[string]bool, spec.PeerOrgs)
+	for i := 1; i <= spec.PeerOrgs; i++ {
+		peerOrgNames[PeerOrgName(i)] = true
+	}
+
+	names := make(map[string]bool, len(spec.Orderer.Organizations))
+	domains := make(map[string]bool, len(spec.Orderer.Organizations))
+	for _, ordOrgSpec := range spec.Orderer.Organizations {
+		if ordOrgSpec.Name == "" {
+			return errors.New("Orderer organization name must be specified")
+		}
+
+		if names[ordOrgSpec.Name] || peerOrgNames[ordOrgSpec.Name] {
+			return fmt.Errorf("Organization name '%s' is used more than once", ordOrgSpec.Name)
+		}
+		names[ordOrgSpec.Name] = true
+
+		if domains[ordOrgSpec.Domain] {
+			return fmt.Errorf("Domain '%s' is used by more than one orderer organization", ordOrgSpec.Domain)
+		}
+		domains[ordOrgSpec.Domain] = true
+	}
+
+	if len(spec.Orderer.Organizations) > 1 && spec.Orderer.Type == OrderingServiceSOLO {
+		return fmt.Errorf("Orderer type '%s' does not support more than one orderer organization", spec.Orderer.Type)
+	}
 
 	return nil
 }
+
+func (spec *NetSpec) validateConsortiums() error {
+	names := make(map[string]bool, len(spec.Consortiums))
+	for _, consortiumSpec := range spec.Consortiums {
+		if consortiumSpec.Name == "" {
+			return errors.New("Consortium name must be specified")
+		}
+
+		if names[consortiumSpec.Name] {
+			return fmt.Errorf("Consortium '%s' is specified more than once", consortiumSpec.Name)
+		}
+		names[consortiumSpec.Name] = true
+
+		for _, orgID := range consortiumSpec.Organizations {
+			if orgID < 1 || orgID > spec.PeerOrgs {
+				return fmt.Errorf("Invalid organization ID '%d' specified for consortium '%s'", orgID, consortiumSpec.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (spec *NetSpec) consortium(name string) *ConsortiumSpec {
+	for _, consortiumSpec := range spec.Consortiums {
+		if consortiumSpec.Name == name {
+			return consortiumSpec
+		}
+	}
+	return nil
+}
+
+func (consortiumSpec *ConsortiumSpec) hasOrganization(orgID int) bool {
+	for _, id := range consortiumSpec.Organizations {
+		if id == orgID {
+			return true
+		}
+	}
+	return false
+}
+
+//OrganizationNames returns the names of every organization in the network
+func (spec *NetSpec) OrganizationNames() []string {
+	var names []string
+	for _, ordOrgSpec := range spec.Orderer.Organizations {
+		names = append(names, ordOrgSpec.Name)
+	}
+	for i := 1; i <= spec.PeerOrgs; i++ {
+		names = append(names, PeerOrgName(i))
+	}
+	return names
+}
+
+func (batch *BatchSpec) setDefaults(defaults *BatchSpec) {
+	if batch.Timeout == "" {
+		batch.Timeout = defaults.Timeout
+	}
+	if batch.MaxMessageCount == 0 {
+		batch.MaxMessageCount = defaults.MaxMessageCount
+	}
+	if batch.AbsoluteMaxBytes == "" {
+		batch.AbsoluteMaxBytes = defaults.AbsoluteMaxBytes
+	}
+	if batch.PreferredMaxBytes == "" {
+		batch.PreferredMaxBytes = defaults.PreferredMaxBytes
+	}
+}
+
+func (batch *BatchSpec) validate() error {
+	timeout, err := time.ParseDuration(batch.Timeout)
+	if err != nil {
+		return fmt.Errorf("invalid timeout '%s'", batch.Timeout)
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got '%s'", batch.Timeout)
+	}
+
+	if batch.MaxMessageCount <= 0 {
+		return fmt.Errorf("maxMessageCount must be greater than 0, got %d", batch.MaxMessageCount)
+	}
+
+	absoluteMaxBytes, err := ParseByteSize(batch.AbsoluteMaxBytes)
+	if err != nil {
+		return err
+	}
+	if absoluteMaxBytes == 0 {
+		return errors.New("absoluteMaxBytes must be greater than 0")
+	}
+
+	preferredMaxBytes, err := ParseByteSize(batch.PreferredMaxBytes)
+	if err != nil {
+		return err
+	}
+	if preferredMaxBytes == 0 {
+		return errors.New("preferredMaxBytes must be greater than 0")
+	}
+
+	if preferredMaxBytes > absoluteMaxBytes {
+		return fmt.Errorf("preferredMaxBytes (%s) must not exceed absoluteMaxBytes (%s)", batch.PreferredMaxBytes, batch.AbsoluteMaxBytes)
+	}
+
+	return nil
+}
+
+var byteSizeRegexp = regexp.MustCompile(`^(?i)([0-9]+)\s*([KMG]?)B?$`)
+
+//ParseByteSize parses sizes such as "99 MB" or "512KB" as accepted by configtxgen
+func ParseByteSize(size string) (uint32, error) {
+	matches := byteSizeRegexp.FindStringSubmatch(strings.TrimSpace(size))
+	if matches == nil {
+		return 0, fmt.Errorf("invalid byte size '%s'", size)
+	}
+
+	value, err := strconv.ParseUint(matches[1], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size '%s'", size)
+	}
+
+	switch strings.ToUpper(matches[2]) {
+	case "G":
+		value <<= 30
+	case "M":
+		value <<= 20
+	case "K":
+		value <<= 10
+	}
+
+	if value > 1<<32-1 {
+		return 0, fmt.Errorf("byte size '%s' exceeds 4 GB", size)
+	}
+
+	return uint32(value), nil
+}
+
+func randomPassword() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("Error generating password: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,58 @@
+package netSpec
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+//FabricVersion is the Fabric release of the images in use
+type FabricVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+//Matches tags such as 1.3.0, 2.2 or amd64-1.4.3
+var fabricVersionRegexp = regexp.MustCompile(`([0-9]+)\.([0-9]+)(?:\.([0-9]+))?`)
+
+//ParseFabricVersion extracts the Fabric release from an image version tag
+func ParseFabricVersion(tag string) (*FabricVersion, error) {
+	matches := fabricVersionRegexp.FindStringSubmatch(tag)
+	if matches == nil {
+		return nil, fmt.Errorf("Unable to infer Fabric release from version tag '%s'", tag)
+	}
+
+	version := &FabricVersion{}
+	version.Major, _ = strconv.Atoi(matches[1])
+	version.Minor, _ = strconv.Atoi(matches[2])
+	if matches[3] != "" {
+		version.Patch, _ = strconv.Atoi(matches[3])
+	}
+
+	return version, nil
+}
+
+//AtLeast reports whether the version is equal or later than major.minor.patch
+func (v *FabricVersion) AtLeast(major, minor, patch int) bool {
+	if v.Major != major {
+		return v.Major > major
+	}
+	if v.Minor != minor {
+		return v.Minor > minor
+	}
+	return v.Patch >= patch
+}
+
+func (v *FabricVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+//FabricVersion returns the release of the Fabric images in use, or nil if it can not be inferred from FABRIC_VERSION_TAG
+func (spec *NetSpec) FabricVersion() *FabricVersion {
+	version, err := ParseFabricVersion(spec.FabricVersionTag)
+	if err != nil {
+		return nil
+	}
+	return version
+}
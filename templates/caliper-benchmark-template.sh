@@ -0,0 +1,33 @@
+#!/bin/bash
+#
+# Runs the Caliper benchmark of a chaincode against the network
+# usage: benchmark.sh <chaincode>
+#
+
+script_full_path=$(cd "$(dirname "$0")" && pwd)
+network_path=$(dirname "$script_full_path")
+
+if [ -z "$1" ]; then
+    echo "usage: $0 <chaincode>"
+    echo "chaincodes:{{range .Chaincodes}} {{.Name}}{{end}}"
+    exit 1
+fi
+
+if [ ! -f "$script_full_path/benchmarks/$1.yaml" ]; then
+    echo "No benchmark found for chaincode '$1'"
+    exit 1
+fi
+
+# Install Caliper and bind it to the Fabric SDK the first time
+if [ ! -d "$script_full_path/node_modules" ]; then
+    (cd "$script_full_path" && \
+        npm init -y > /dev/null && \
+        npm install --only=prod @hyperledger/caliper-cli@0.5.0 && \
+        npx caliper bind --caliper-bind-sut {{.Caliper.SUT}}) || exit 1
+fi
+
+cd "$script_full_path" && npx caliper launch manager \
+    --caliper-workspace "$network_path" \
+    --caliper-networkconfig caliper/networkconfig.yaml \
+    --caliper-benchconfig caliper/benchmarks/$1.yaml \
+    --caliper-flow-only-test
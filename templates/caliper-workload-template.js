@@ -0,0 +1,25 @@
+'use strict';
+
+//
+// Caliper workload module sending the transaction described by the round arguments
+//
+const { WorkloadModuleBase } = require('@hyperledger/caliper-core');
+
+class ChaincodeWorkload extends WorkloadModuleBase {
+    async submitTransaction() {
+        const args = this.roundArguments;
+        await this.sutAdapter.sendRequests({
+            channel: args.channel,
+            contractId: args.contractId,
+            contractFunction: args.contractFunction,
+            contractArguments: args.contractArguments,
+            readOnly: args.readOnly
+        });
+    }
+}
+
+function createWorkloadModule() {
+    return new ChaincodeWorkload();
+}
+
+module.exports.createWorkloadModule = createWorkloadModule;
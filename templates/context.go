@@ -0,0 +1,74 @@
+package templates
+
+import (
+	"github.com/ibm-silvergate/netcomposer/netModel"
+	"github.com/ibm-silvergate/netcomposer/netSpec"
+)
+
+//Context is the data of every template: the resolved network model, whose fields are promoted, the spec it was built from,
+//the entity of the current iteration and the generation settings
+type Context struct {
+	*netModel.NetModel
+	//Spec holds the defaulted spec, e.g. .Spec.PeerOrgUsers
+	Spec *netSpec.NetSpec
+	//Organization, Peer, Channel and Chaincode are the entity of the current iteration, nil when not iterating over them
+	Organization *netModel.Organization
+	Peer         *netModel.Peer
+	Channel      *netModel.Channel
+	Chaincode    *netModel.Chaincode
+	//InNetwork is set while rendering the in-network variant of connection profiles
+	InNetwork bool
+	//HostProfiles and NetworkProfiles report the connection profile variants selected with -profiles
+	HostProfiles    bool
+	NetworkProfiles bool
+	Paths           *Paths
+	//Version is the netcomposer version
+	Version string
+}
+
+//Paths locates the generated network, every path includes the -output directory
+type Paths struct {
+	Network       string
+	Volumes       string
+	CryptoConfig  string
+	Chaincodes    string
+	Genesis       string
+	Channels      string
+	NetworkConfig string
+}
+
+//WithOrganization returns a copy of the context iterating over an organization
+func (ctx *Context) WithOrganization(org *netModel.Organization) *Context {
+	copy := *ctx
+	copy.Organization = org
+	return &copy
+}
+
+//WithPeer returns a copy of the context iterating over a peer and its organization
+func (ctx *Context) WithPeer(peer *netModel.Peer) *Context {
+	copy := *ctx
+	copy.Organization = peer.Organization
+	copy.Peer = peer
+	return &copy
+}
+
+//WithChannel returns a copy of the context iterating over a channel
+func (ctx *Context) WithChannel(channel *netModel.Channel) *Context {
+	copy := *ctx
+	copy.Channel = channel
+	return &copy
+}
+
+//WithChaincode returns a copy of the context iterating over a chaincode
+func (ctx *Context) WithChaincode(chaincode *netModel.Chaincode) *Context {
+	copy := *ctx
+	copy.Chaincode = chaincode
+	return &copy
+}
+
+//WithVariant returns a copy of the context rendering the host or the in-network variant of connection profiles
+func (ctx *Context) WithVariant(inNetwork bool) *Context {
+	copy := *ctx
+	copy.InNetwork = inNetwork
+	return &copy
+}
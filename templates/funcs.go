@@ -0,0 +1,257 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/ibm-silvergate/netcomposer/netModel"
+	yaml "gopkg.in/yaml.v2"
+)
+
+//Funcs returns the functions available to templates, relative files are read from the network and crypto material paths
+func Funcs(networkPath, cryptoConfigPath string) template.FuncMap {
+	return template.FuncMap{
+		"Sequence": sequence,
+		"ToLower":  strings.ToLower,
+		"Inc":      inc,
+		//CryptoFile returns the content of a file of the generated crypto material
+		"CryptoFile": func(relativePath string) (string, error) {
+			return readFile(cryptoConfigPath, relativePath)
+		},
+		//PEM returns a file of the generated crypto material as a quoted string, to be embedded in YAML or JSON
+		"PEM": func(relativePath string) (string, error) {
+			content, err := readFile(cryptoConfigPath, relativePath)
+			return fmt.Sprintf("%q", content), err
+		},
+		//ReadFile returns the content of a file of the generated network
+		"ReadFile": func(relativePath string) (string, error) {
+			return readFile(networkPath, relativePath)
+		},
+		"PeersOfOrg":    peersOfOrg,
+		"CAsOfOrg":      casOfOrg,
+		"OrderersOfOrg": orderersOfOrg,
+		"AnchorPeers":   anchorPeers,
+		"FirstEndorser": firstEndorser,
+		"PeerRoles":     peerRoles,
+		"NodeID":        nodeID,
+		"Port":          port,
+		"HostPort":      hostPort,
+		"Join":          join,
+		"ToJSON":        toJSON,
+		"ToYAML":        toYAML,
+		"Indent":        indent,
+		"Default":       defaultValue,
+		"B64Enc":        b64enc,
+	}
+}
+
+func sequence(start, end int) (stream chan int) {
+	stream = make(chan int)
+	go func() {
+		for i := start; i <= end; i++ {
+			stream <- i
+		}
+		close(stream)
+	}()
+	return
+}
+
+func inc(val int) int {
+	return val + 1
+}
+
+func readFile(basePath, relativePath string) (string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(basePath, relativePath))
+	return string(content), err
+}
+
+//peersOfOrg returns the peers of the organization, e.g. {{range PeersOfOrg $ "org1"}}
+func peersOfOrg(ctx *Context, orgName string) []*netModel.Peer {
+	peers := make([]*netModel.Peer, 0)
+	for _, peer := range ctx.Peers {
+		if peer.Organization != nil && peer.Organization.Name == orgName {
+			peers = append(peers, peer)
+		}
+	}
+	return peers
+}
+
+//casOfOrg returns the CAs of the organization, e.g. {{range CAsOfOrg $ "org1"}}
+func casOfOrg(ctx *Context, orgName string) []*netModel.CA {
+	cas := make([]*netModel.CA, 0)
+	for _, ca := range ctx.CAs {
+		if ca.Organization != nil && ca.Organization.Name == orgName {
+			cas = append(cas, ca)
+		}
+	}
+	return cas
+}
+
+//orderersOfOrg returns the ordering nodes of the organization, e.g. {{range OrderersOfOrg $ "ordererOrg"}}
+func orderersOfOrg(ctx *Context, orgName string) []*netModel.Orderer {
+	orderers := make([]*netModel.Orderer, 0)
+	for _, orderer := range ctx.Orderers {
+		if orderer.Organization != nil && orderer.Organization.Name == orgName {
+			orderers = append(orderers, orderer)
+		}
+	}
+	return orderers
+}
+
+//anchorPeers returns the peers announced as anchor peers of the organization, every peer of the organization
+func anchorPeers(org *netModel.Organization) []*netModel.Peer {
+	return org.Peers
+}
+
+//firstEndorser returns the first endorsing peer of the channel, the first peer of the channel when none endorses
+func firstEndorser(channel *netModel.Channel) (*netModel.Peer, error) {
+	var first *netModel.Peer
+	for _, chOrg := range channel.Organizations {
+		for _, chPeer := range chOrg.Peers {
+			if chPeer.Endorser {
+				return chPeer.Peer, nil
+			}
+			if first == nil {
+				first = chPeer.Peer
+			}
+		}
+	}
+	if first == nil {
+		return nil, fmt.Errorf("Channel '%s' has no peers", channel.Name)
+	}
+	return first, nil
+}
+
+//peerRoles returns the roles of a peer in a channel, e.g. endorser, query, ledger, events, committer when it has none
+func peerRoles(chPeer *netModel.ChannelPeer) string {
+	var roles []string
+	if chPeer.Endorser {
+		roles = append(roles, "endorser")
+	}
+	if chPeer.QueryChaincode {
+		roles = append(roles, "query")
+	}
+	if chPeer.QueryLedger {
+		roles = append(roles, "ledger")
+	}
+	if chPeer.EventSource {
+		roles = append(roles, "events")
+	}
+	if len(roles) == 0 {
+		return "committer"
+	}
+	return strings.Join(roles, ", ")
+}
+
+//nodeID turns a name into an identifier of letters, digits and underscores, e.g. for Mermaid nodes
+func nodeID(name string) string {
+	return nodeIDRegexp.ReplaceAllString(name, "_")
+}
+
+var nodeIDRegexp = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+//ports indexes the [host, container] ports of every node and service by container name
+func ports(ctx *Context) map[string][2]int {
+	index := make(map[string][2]int)
+	for _, orderer := range ctx.Orderers {
+		index[orderer.Name] = [2]int{orderer.ExposedPort, orderer.Port}
+	}
+	for _, peer := range ctx.Peers {
+		index[peer.Name] = [2]int{peer.ExposedPort, peer.Port}
+		if peer.DB != nil && peer.DB.Name != "" {
+			index[peer.DB.Name] = [2]int{peer.DB.ExposedPort, peer.DB.Port}
+		}
+	}
+	for _, ca := range ctx.CAs {
+		index[ca.Name] = [2]int{ca.ExposedPort, ca.Port}
+	}
+	if ctx.Observability != nil {
+		for _, service := range []*netModel.MonitoringService{ctx.Observability.Prometheus, ctx.Observability.Grafana} {
+			if service != nil {
+				index[service.Name] = [2]int{service.ExposedPort, service.Port}
+			}
+		}
+	}
+	if ctx.Explorer != nil {
+		index[ctx.Explorer.Name] = [2]int{ctx.Explorer.ExposedPort, ctx.Explorer.Port}
+	}
+	return index
+}
+
+//port returns the container port of a node or service, e.g. {{Port $ "peer1.org1.samplenet.com"}}
+func port(ctx *Context, name string) (int, error) {
+	if ports, found := ports(ctx)[name]; found {
+		return ports[1], nil
+	}
+	return 0, fmt.Errorf("Unknown node or service '%s'", name)
+}
+
+//hostPort returns the port published on the docker host by a node or service
+func hostPort(ctx *Context, name string) (int, error) {
+	if ports, found := ports(ctx)[name]; found {
+		return ports[0], nil
+	}
+	return 0, fmt.Errorf("Unknown node or service '%s'", name)
+}
+
+//join formats the elements of a slice and joins them with the separator, e.g. {{Join ", " .Users}}
+func join(separator string, list interface{}) (string, error) {
+	value := reflect.ValueOf(list)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return "", fmt.Errorf("Join expects a list, got %T", list)
+	}
+
+	items := make([]string, value.Len())
+	for i := range items {
+		items[i] = fmt.Sprint(value.Index(i).Interface())
+	}
+	return strings.Join(items, separator), nil
+}
+
+func toJSON(value interface{}) (string, error) {
+	content, err := json.Marshal(value)
+	return string(content), err
+}
+
+func toYAML(value interface{}) (string, error) {
+	content, err := yaml.Marshal(value)
+	return strings.TrimSuffix(string(content), "\n"), err
+}
+
+//indent prefixes every line but the first with spaces, so multi-line values can follow a key, e.g. {{ToYAML .ACLs | Indent 4}}
+func indent(spaces int, text string) string {
+	var buffer bytes.Buffer
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			buffer.WriteString("\n")
+			if line != "" {
+				buffer.WriteString(strings.Repeat(" ", spaces))
+			}
+		}
+		buffer.WriteString(line)
+	}
+	return buffer.String()
+}
+
+//defaultValue returns the value unless it is empty, e.g. {{.Description | Default "sample network"}}
+func defaultValue(def, value interface{}) interface{} {
+	if value == nil {
+		return def
+	}
+	if v := reflect.ValueOf(value); v.IsZero() || ((v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0) {
+		return def
+	}
+	return value
+}
+
+func b64enc(text string) string {
+	return base64.StdEncoding.EncodeToString([]byte(text))
+}
@@ -0,0 +1,404 @@
+package templates
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/ibm-silvergate/netcomposer/netModel"
+)
+
+//testContext returns the context of a small network: an orderer organization, org1 with two peers, the second one
+//running CouchDB, org2 with one peer, a channel joined by org1 and a channel without peers
+func testContext() *Context {
+	ordererOrg := &netModel.Organization{Name: "ordererOrg", FullName: "ordererOrg.testnet.com"}
+	org1 := &netModel.Organization{Name: "org1", FullName: "org1.testnet.com"}
+	org2 := &netModel.Organization{Name: "org2", FullName: "org2.testnet.com"}
+
+	orderer := &netModel.Orderer{Name: "orderer1.ordererOrg.testnet.com", Organization: ordererOrg, ExposedPort: 7050, Port: 7050}
+	peer1 := &netModel.Peer{Name: "peer1.org1.testnet.com", Organization: org1, ExposedPort: 7051, Port: 7051}
+	peer2 := &netModel.Peer{Name: "peer2.org1.testnet.com", Organization: org1, ExposedPort: 8051, Port: 7051,
+		DB: &netModel.PeerDB{Name: "couchdb2.org1.testnet.com", ExposedPort: 5985, Port: 5984}}
+	peer3 := &netModel.Peer{Name: "peer1.org2.testnet.com", Organization: org2, ExposedPort: 9051, Port: 7051}
+	org1.Peers = []*netModel.Peer{peer1, peer2}
+	org2.Peers = []*netModel.Peer{peer3}
+
+	channel := &netModel.Channel{
+		Name: "testchannel",
+		Organizations: []*netModel.ChannelOrg{
+			{Organization: org1, Peers: []*netModel.ChannelPeer{
+				{Peer: peer1, QueryLedger: true},
+				{Peer: peer2, Endorser: true, QueryChaincode: true},
+			}},
+		},
+	}
+	empty := &netModel.Channel{Name: "emptychannel"}
+
+	return &Context{
+		NetModel: &netModel.NetModel{
+			Name:                 "testnet",
+			OrdererOrganizations: []*netModel.Organization{ordererOrg},
+			PeerOrganizations:    []*netModel.Organization{org1, org2},
+			Orderers:             []*netModel.Orderer{orderer},
+			Peers:                []*netModel.Peer{peer1, peer2, peer3},
+			CAs: []*netModel.CA{
+				{Name: "ca.org1.testnet.com", Organization: org1, ExposedPort: 7054, Port: 7054},
+				{Name: "ca.org2.testnet.com", Organization: org2, ExposedPort: 8054, Port: 7054},
+			},
+			Channels: map[string]*netModel.Channel{channel.Name: channel, empty.Name: empty},
+			Observability: &netModel.Observability{
+				Prometheus: &netModel.MonitoringService{Name: "prometheus.testnet.com", ExposedPort: 9090, Port: 9090},
+			},
+			Explorer: &netModel.Explorer{Name: "explorer.testnet.com", ExposedPort: 8080, Port: 8080},
+		},
+	}
+}
+
+func names(value interface{}) []string {
+	list := reflect.ValueOf(value)
+	result := make([]string, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		result = append(result, list.Index(i).Elem().FieldByName("Name").String())
+	}
+	return result
+}
+
+func TestOfOrg(t *testing.T) {
+	ctx := testContext()
+	tests := []struct {
+		name     string
+		nodes    interface{}
+		expected []string
+	}{
+		{"peers of org1", peersOfOrg(ctx, "org1"), []string{"peer1.org1.testnet.com", "peer2.org1.testnet.com"}},
+		{"peers of org2", peersOfOrg(ctx, "org2"), []string{"peer1.org2.testnet.com"}},
+		{"peers of unknown org", peersOfOrg(ctx, "org3"), []string{}},
+		{"CAs of org2", casOfOrg(ctx, "org2"), []string{"ca.org2.testnet.com"}},
+		{"CAs of orderer org", casOfOrg(ctx, "ordererOrg"), []string{}},
+		{"orderers of orderer org", orderersOfOrg(ctx, "ordererOrg"), []string{"orderer1.ordererOrg.testnet.com"}},
+		{"orderers of org1", orderersOfOrg(ctx, "org1"), []string{}},
+		{"anchor peers of org1", anchorPeers(ctx.PeerOrganizations[0]), []string{"peer1.org1.testnet.com", "peer2.org1.testnet.com"}},
+	}
+
+	for _, test := range tests {
+		if actual := names(test.nodes); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s: got %v, expected %v", test.name, actual, test.expected)
+		}
+	}
+}
+
+func TestFirstEndorser(t *testing.T) {
+	ctx := testContext()
+	noEndorser := &netModel.Channel{Name: "noendorser", Organizations: []*netModel.ChannelOrg{
+		{Organization: ctx.PeerOrganizations[1], Peers: []*netModel.ChannelPeer{{Peer: ctx.Peers[2]}}},
+	}}
+
+	tests := []struct {
+		channel  *netModel.Channel
+		expected string
+		err      string
+	}{
+		{ctx.Channels["testchannel"], "peer2.org1.testnet.com", ""},
+		{noEndorser, "peer1.org2.testnet.com", ""},
+		{ctx.Channels["emptychannel"], "", "Channel 'emptychannel' has no peers"},
+	}
+
+	for _, test := range tests {
+		peer, err := firstEndorser(test.channel)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: got error %v, expected %s", test.channel.Name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.channel.Name, err)
+		} else if peer.Name != test.expected {
+			t.Errorf("%s: got %s, expected %s", test.channel.Name, peer.Name, test.expected)
+		}
+	}
+}
+
+func TestPorts(t *testing.T) {
+	ctx := testContext()
+	tests := []struct {
+		name     string
+		port     int
+		hostPort int
+		err      string
+	}{
+		{"orderer1.ordererOrg.testnet.com", 7050, 7050, ""},
+		{"peer2.org1.testnet.com", 7051, 8051, ""},
+		{"couchdb2.org1.testnet.com", 5984, 5985, ""},
+		{"ca.org2.testnet.com", 7054, 8054, ""},
+		{"prometheus.testnet.com", 9090, 9090, ""},
+		{"explorer.testnet.com", 8080, 8080, ""},
+		{"peer9.org1.testnet.com", 0, 0, "Unknown node or service 'peer9.org1.testnet.com'"},
+	}
+
+	for _, test := range tests {
+		containerPort, err := port(ctx, test.name)
+		exposedPort, hostErr := hostPort(ctx, test.name)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err || hostErr == nil || hostErr.Error() != test.err {
+				t.Errorf("%s: got errors %v and %v, expected %s", test.name, err, hostErr, test.err)
+			}
+			continue
+		}
+		if err != nil || hostErr != nil {
+			t.Errorf("%s: unexpected errors %v and %v", test.name, err, hostErr)
+			continue
+		}
+		if containerPort != test.port || exposedPort != test.hostPort {
+			t.Errorf("%s: got ports %d and %d, expected %d and %d", test.name, containerPort, exposedPort, test.port, test.hostPort)
+		}
+	}
+}
+
+func TestPeerRoles(t *testing.T) {
+	tests := []struct {
+		peer     *netModel.ChannelPeer
+		expected string
+	}{
+		{&netModel.ChannelPeer{Endorser: true, QueryChaincode: true, QueryLedger: true, EventSource: true}, "endorser, query, ledger, events"},
+		{&netModel.ChannelPeer{QueryLedger: true}, "ledger"},
+		{&netModel.ChannelPeer{}, "committer"},
+	}
+
+	for _, test := range tests {
+		if actual := peerRoles(test.peer); actual != test.expected {
+			t.Errorf("Got %s, expected %s", actual, test.expected)
+		}
+	}
+}
+
+func TestNodeID(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"peer1.org1.testnet.com", "peer1_org1_testnet_com"},
+		{"ordererOrg", "ordererOrg"},
+		{"my-channel", "my_channel"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if actual := nodeID(test.name); actual != test.expected {
+			t.Errorf("%s: got %s, expected %s", test.name, actual, test.expected)
+		}
+	}
+}
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		list     interface{}
+		expected string
+		err      string
+	}{
+		{[]string{"Admin", "User1"}, "Admin, User1", ""},
+		{[]int{1, 2, 3}, "1, 2, 3", ""},
+		{[2]bool{true, false}, "true, false", ""},
+		{[]string{}, "", ""},
+		{"Admin", "", "Join expects a list, got string"},
+	}
+
+	for _, test := range tests {
+		actual, err := join(", ", test.list)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%v: got error %v, expected %s", test.list, err, test.err)
+			}
+			continue
+		}
+		if err != nil || actual != test.expected {
+			t.Errorf("%v: got %q and %v, expected %q", test.list, actual, err, test.expected)
+		}
+	}
+}
+
+func TestToJSONAndYAML(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		json  string
+		yaml  string
+	}{
+		{[]string{"init", "a"}, `["init","a"]`, "- init\n- a"},
+		{map[string]int{"b": 2, "a": 1}, `{"a":1,"b":2}`, "a: 1\nb: 2"},
+		{"text", `"text"`, "text"},
+		{nil, "null", "null"},
+	}
+
+	for _, test := range tests {
+		if actual, err := toJSON(test.value); err != nil || actual != test.json {
+			t.Errorf("ToJSON %v: got %q and %v, expected %q", test.value, actual, err, test.json)
+		}
+		if actual, err := toYAML(test.value); err != nil || actual != test.yaml {
+			t.Errorf("ToYAML %v: got %q and %v, expected %q", test.value, actual, err, test.yaml)
+		}
+	}
+
+	if _, err := toJSON(make(chan int)); err == nil {
+		t.Errorf("ToJSON of a channel: expected an error")
+	}
+}
+
+func TestIndent(t *testing.T) {
+	tests := []struct {
+		spaces   int
+		text     string
+		expected string
+	}{
+		{4, "a: 1\nb: 2", "a: 1\n    b: 2"},
+		{2, "a:\n\n  b: 1", "a:\n\n    b: 1"},
+		{2, "single", "single"},
+		{0, "a\nb", "a\nb"},
+	}
+
+	for _, test := range tests {
+		if actual := indent(test.spaces, test.text); actual != test.expected {
+			t.Errorf("%q: got %q, expected %q", test.text, actual, test.expected)
+		}
+	}
+}
+
+func TestDefaultValue(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected interface{}
+	}{
+		{nil, "default"},
+		{"", "default"},
+		{0, "default"},
+		{false, "default"},
+		{[]string{}, "default"},
+		{map[string]string{}, "default"},
+		{(*netModel.Peer)(nil), "default"},
+		{"value", "value"},
+		{3, 3},
+		{true, true},
+		{[]string{"a"}, []string{"a"}},
+	}
+
+	for _, test := range tests {
+		if actual := defaultValue("default", test.value); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%#v: got %#v, expected %#v", test.value, actual, test.expected)
+		}
+	}
+}
+
+func TestB64Enc(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"admin:adminpw", "YWRtaW46YWRtaW5wdw=="},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if actual := b64enc(test.text); actual != test.expected {
+			t.Errorf("%q: got %s, expected %s", test.text, actual, test.expected)
+		}
+	}
+}
+
+func TestReadFileAndPEM(t *testing.T) {
+	dir, err := ioutil.TempDir("", "netcomposer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	networkPath := filepath.Join(dir, "network")
+	cryptoConfigPath := filepath.Join(dir, "crypto-config")
+	certificate := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	for path, content := range map[string]string{
+		filepath.Join(networkPath, "README.md"):              "network",
+		filepath.Join(cryptoConfigPath, "ca", "ca-cert.pem"): certificate,
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if content, err := readFile(networkPath, "README.md"); err != nil || content != "network" {
+		t.Errorf("readFile: got %q and %v, expected %q", content, err, "network")
+	}
+	if _, err := readFile(networkPath, "missing.md"); err == nil {
+		t.Errorf("readFile of a missing file: expected an error")
+	}
+
+	funcs := Funcs(networkPath, cryptoConfigPath)
+	tests := []struct {
+		function string
+		path     string
+		expected string
+		err      bool
+	}{
+		{"ReadFile", "README.md", "network", false},
+		{"CryptoFile", "ca/ca-cert.pem", certificate, false},
+		{"PEM", "ca/ca-cert.pem", `"-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"`, false},
+		{"ReadFile", "ca/ca-cert.pem", "", true},
+		{"PEM", "missing.pem", "", true},
+	}
+
+	for _, test := range tests {
+		actual, err := funcs[test.function].(func(string) (string, error))(test.path)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s %s: expected an error", test.function, test.path)
+			}
+			continue
+		}
+		if err != nil || actual != test.expected {
+			t.Errorf("%s %s: got %q and %v, expected %q", test.function, test.path, actual, err, test.expected)
+		}
+	}
+}
+
+//TestContextFuncs renders helpers taking the context from templates, as $ and as a copy iterating over an entity
+func TestContextFuncs(t *testing.T) {
+	ctx := testContext()
+	tests := []struct {
+		template string
+		data     *Context
+		expected string
+		err      string
+	}{
+		{`{{range PeersOfOrg $ "org1"}}{{.Name}} {{end}}`, ctx, "peer1.org1.testnet.com peer2.org1.testnet.com ", ""},
+		{`{{range CAsOfOrg $ .Organization.Name}}{{.Name}}{{end}}`, ctx.WithOrganization(ctx.PeerOrganizations[1]), "ca.org2.testnet.com", ""},
+		{`{{range OrderersOfOrg $ "ordererOrg"}}{{NodeID .Name}}{{end}}`, ctx, "orderer1_ordererOrg_testnet_com", ""},
+		{`{{with .Peer}}{{Port $ .Name}}:{{HostPort $ .Name}}{{end}}`, ctx.WithPeer(ctx.Peers[1]), "7051:8051", ""},
+		{`{{(FirstEndorser .Channel).Name}}`, ctx.WithChannel(ctx.Channels["testchannel"]), "peer2.org1.testnet.com", ""},
+		{`{{range (index .Channel.Organizations 0).Peers}}{{PeerRoles .}};{{end}}`, ctx.WithChannel(ctx.Channels["testchannel"]), "ledger;endorser, query;", ""},
+		{`{{Port $ "unknown"}}`, ctx, "", "Unknown node or service 'unknown'"},
+	}
+
+	for _, test := range tests {
+		tmpl, err := template.New("test").Funcs(Funcs("", "")).Parse(test.template)
+		if err != nil {
+			t.Fatalf("%s: %v", test.template, err)
+		}
+
+		var output bytes.Buffer
+		err = tmpl.Execute(&output, test.data)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: got error %v, expected %s", test.template, err, test.err)
+			}
+			continue
+		}
+		if err != nil || output.String() != test.expected {
+			t.Errorf("%s: got %q and %v, expected %q", test.template, output.String(), err, test.expected)
+		}
+	}
+}
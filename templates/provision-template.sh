@@ -42,6 +42,62 @@ function createChannel() {
     {{- end}}
 }
 
+function joinOrdererToChannel() {
+    #$1 peer cli from which the request is made
+    #$2 orderer admin endpoint
+    #$3 channel
+    #$4 orderer organization admin tls directory, holding its client certificate and the tls ca certificate
+    docker exec $1 /bin/sh -c "cd channel-artifacts; osnadmin channel join --channelID $3 --config-block $3.block -o '$2' --ca-file '$4/ca.crt' --client-cert '$4/client.crt' --client-key '$4/client.key'"
+}
+
+function updateChannelBatch() {
+    #$1 peer cli from which the update is made
+    #$2 orderer to which the request is sent
+    #$3 channel
+    #$4 orderer tls ca certificate
+    #$5 batch timeout
+    #$6 max message count
+    #$7 absolute max bytes
+    #$8 preferred max bytes
+    #$9... orderer organization admins as <MSP ID>:<MSP path>
+    # The update modifies the Orderer group, so it is signed by the orderer organization admins
+    local admins=("${@:9}")
+    local workdir=/tmp/$3-batch-update
+
+    docker exec \
+        -e CORE_PEER_LOCALMSPID=${admins[0]%%:*} \
+        -e CORE_PEER_MSPCONFIGPATH=${admins[0]#*:} \
+        $1 /bin/bash -c "
+        set -e
+        rm -rf $workdir && mkdir -p $workdir && cd $workdir
+        peer channel fetch config config_block.pb -o '$2' -c $3{{if .TLSEnabled}} --tls{{end}} --cafile '$4'
+        configtxlator proto_decode --input config_block.pb --type common.Block | jq .data.data[0].payload.data.config > config.json
+        jq '.channel_group.groups.Orderer.values.BatchTimeout.value.timeout = \"$5\"
+            | .channel_group.groups.Orderer.values.BatchSize.value.max_message_count = $6
+            | .channel_group.groups.Orderer.values.BatchSize.value.absolute_max_bytes = $7
+            | .channel_group.groups.Orderer.values.BatchSize.value.preferred_max_bytes = $8' config.json > modified_config.json
+        configtxlator proto_encode --input config.json --type common.Config --output config.pb
+        configtxlator proto_encode --input modified_config.json --type common.Config --output modified_config.pb
+        configtxlator compute_update --channel_id $3 --original config.pb --updated modified_config.pb --output config_update.pb
+        configtxlator proto_decode --input config_update.pb --type common.ConfigUpdate > config_update.json
+        echo '{\"payload\":{\"header\":{\"channel_header\":{\"channel_id\":\"$3\",\"type\":2}},\"data\":{\"config_update\":'\$(cat config_update.json)'}}}' | jq . > config_update_envelope.json
+        configtxlator proto_encode --input config_update_envelope.json --type common.Envelope --output config_update_envelope.pb
+    " || return 1
+
+    # The submitter signs on update, the remaining admins sign beforehand
+    for admin in "${admins[@]:1}"; do
+        docker exec \
+            -e CORE_PEER_LOCALMSPID=${admin%%:*} \
+            -e CORE_PEER_MSPCONFIGPATH=${admin#*:} \
+            $1 peer channel signconfigtx -f $workdir/config_update_envelope.pb || return 1
+    done
+
+    docker exec \
+        -e CORE_PEER_LOCALMSPID=${admins[0]%%:*} \
+        -e CORE_PEER_MSPCONFIGPATH=${admins[0]#*:} \
+        $1 /bin/bash -c "peer channel update -f $workdir/config_update_envelope.pb -o '$2' -c $3{{if .TLSEnabled}} --tls{{end}} --cafile '$4'"
+}
+
 function joinPeerToChannel() {
     #$1 peer cli
     #$2 channel
@@ -98,13 +154,26 @@ panicOnError $? "Basic containers successfully started!" "Error while starting b
 # wait for containers to start
 sleep {{.ChannelCreationDelay}}
 
-ORDERER_CA='/etc/hyperledger/fabric/crypto-config/orderer/msp/tlscacerts/tlsca.{{$.Domain}}-cert.pem'
+ORDERER_CA='/etc/hyperledger/fabric/crypto-config/orderer/msp/tlscacerts/tlsca.{{$.OrdererOrganization.Domain}}-cert.pem'
 
 {{range $i,$ch := $.Channels}}
-{{- $peer:= (index (index $ch.Organizations 0).Peers 0).Peer}}
+{{- $peer:= FirstEndorser $ch}}
 {{- $orderer:= index $.Orderers 0}}
+{{- if $.ChannelParticipation}}
+{{range $.Orderers -}}
+joinOrdererToChannel 'cli.{{$peer.Name}}' '{{.Name}}:{{.AdminPort}}' '{{$ch.Name}}' '/etc/hyperledger/fabric/crypto-config/ordererOrganizations/{{.Organization.Domain}}/users/Admin@{{.Organization.Domain}}/tls'
+panicOnError $? "Orderer '{{.Name}}' successfully joined channel '{{$ch.Name}}'" "Error while orderer '{{.Name}}' joins channel '{{$ch.Name}}'"
+{{end -}}
+{{- else}}
 createChannel 'cli.{{$peer.Name}}' '{{$orderer.Name}}:{{$orderer.Port}}' '{{.Name}}' $ORDERER_CA
 panicOnError $? "Channel '{{.Name}}' successfully created!" "Error while creating channel '{{.Name}}'"
+{{- end}}
+{{- if and .BatchOverride (not $.ChannelParticipation)}}
+
+updateChannelBatch 'cli.{{$peer.Name}}' '{{$orderer.Name}}:{{$orderer.Port}}' '{{.Name}}' $ORDERER_CA '{{.Batch.Timeout}}' {{.Batch.MaxMessageCount}} {{.Batch.AbsoluteMaxBytes}} {{.Batch.PreferredMaxBytes}}{{range $.OrdererOrganizations}} \
+    '{{.Name}}MSP:/etc/hyperledger/fabric/crypto-config/ordererOrganizations/{{.Domain}}/users/Admin@{{.Domain}}/msp'{{end}}
+panicOnError $? "Batch configuration of channel '{{.Name}}' successfully updated!" "Error while updating batch configuration of channel '{{.Name}}'"
+{{- end}}
 
 {{range .Organizations}}{{range .Peers -}}
 joinPeerToChannel 'cli.{{.Peer.Name}}' '{{$ch.Name}}'
@@ -122,3 +191,12 @@ installChaincode 'cli.{{.Peer.Name}}' '{{$cc.Name}}' '{{$cc.Version}}' '{{$cc.La
 panicOnError $? "Chaincode {{$cc.Name}} sucessfully installed in peer {{.Peer.Name}}" "Error while installing chaincode {{$cc.Name}} in peer {{.Peer.Name}}"
 {{- end}}
 {{end}}{{end}}{{end}}{{end}}
+{{- if $.Caliper}}
+# Chaincodes are instantiated so their Caliper benchmarks can run
+{{- $orderer:= index $.Orderers 0}}
+{{range $cc := $.Chaincodes}}{{range $ch := .Channels}}
+{{- $peer:= FirstEndorser $ch}}
+instantiateChaincode 'cli.{{$peer.Name}}' '{{$orderer.Name}}:{{$orderer.Port}}' {{$.TLSEnabled}} $ORDERER_CA '{{$ch.Name}}' '{{$cc.Name}}' '{{$cc.Version}}' '{"Args":{{ToJSON $cc.Benchmark.InitArgs}}}' 'OR({{range $j, $org := $ch.Organizations}}{{if $j}},{{end}}"{{$org.Organization.Name}}MSP.peer"{{end}})'
+panicOnError $? "Chaincode {{$cc.Name}} successfully instantiated on channel {{$ch.Name}}" "Error while instantiating chaincode {{$cc.Name}} on channel {{$ch.Name}}"
+{{end}}{{end}}
+{{- end}}
@@ -31,10 +31,11 @@ THIRDPARTY_IMAGES=(fabric-zookeeper fabric-couchdb fabric-kafka)
 for image in ${THIRDPARTY_IMAGES[@]}; do
     pullDockerImage ${DOCKER_NS}/${image}:${THIRDPARTY_VERSION_TAG}
 done
+{{- if .Explorer}}
 
-
-
-
-
-
-
+# explorer images
+EXPLORER_VERSION_TAG={{.ExplorerVersionTag}}
+for image in explorer explorer-db; do
+    pullDockerImage hyperledger/${image}:${EXPLORER_VERSION_TAG}
+done
+{{- end}}
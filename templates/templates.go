@@ -0,0 +1,234 @@
+//Package templates holds the stock templates compiled into netcomposer
+package templates
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+//GeneratorsManifest is the file listing the artifacts rendered from templates
+const GeneratorsManifest = "generators.yaml"
+
+//Constants used to identify the entities generators iterate over
+const (
+	ForEachOnce      string = "once"
+	ForEachOrg       string = "org"
+	ForEachPeer      string = "peer"
+	ForEachChannel   string = "channel"
+	ForEachChaincode string = "chaincode"
+)
+
+//Generator renders a template into one file, or one file per entity of the network
+type Generator struct {
+	Name     string `yaml:"name"`
+	Template string `yaml:"template"`
+	//Target is a template evaluated for each entity, relative to the network directory
+	Target  string `yaml:"target"`
+	ForEach string `yaml:"foreach"`
+	//Mode is an octal file mode, e.g. "0755"
+	Mode string `yaml:"mode"`
+	//Disabled removes a generator of a manifest with lower precedence, e.g. a stock generator
+	Disabled bool        `yaml:"disabled"`
+	FileMode os.FileMode `yaml:"-"`
+}
+
+type manifest struct {
+	Generators []*Generator `yaml:"generators"`
+}
+
+//go:embed *-template.* generators.yaml
+var stock embed.FS
+
+//Names returns the names of the stock templates and the generators manifest, sorted
+func Names() []string {
+	entries, err := fs.ReadDir(stock, ".")
+	if err != nil {
+		panic(err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+//Load returns the content of a template, the first override directory holding a file with the same name takes precedence over the stock template
+func Load(name string, overrideDirs []string) ([]byte, error) {
+	for _, dir := range overrideDirs {
+		content, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return content, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	content, err := stock.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("Template '%s' not found in override directories %v nor in stock templates", name, overrideDirs)
+	}
+	return content, nil
+}
+
+//LoadGenerators reads the generators manifest. Manifests in override directories are merged into the stock one by
+//generator name, the first override directory taking precedence: set fields replace those of the generator with the same
+//name, new generators are appended and disabled generators are removed
+func LoadGenerators(overrideDirs []string) ([]*Generator, error) {
+	content, err := stock.ReadFile(GeneratorsManifest)
+	if err != nil {
+		return nil, err
+	}
+	generators, err := parseGenerators(content, GeneratorsManifest)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(overrideDirs) - 1; i >= 0; i-- {
+		manifestFile := filepath.Join(overrideDirs[i], GeneratorsManifest)
+		content, err := ioutil.ReadFile(manifestFile)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		overrides, err := parseGenerators(content, manifestFile)
+		if err != nil {
+			return nil, err
+		}
+		generators = mergeGenerators(generators, overrides)
+	}
+
+	enabled := make([]*Generator, 0, len(generators))
+	for _, generator := range generators {
+		if generator.Disabled {
+			continue
+		}
+		generator.setDefaults()
+		if err := generator.validate(); err != nil {
+			return nil, fmt.Errorf("Invalid generator of %s: %v", GeneratorsManifest, err)
+		}
+		enabled = append(enabled, generator)
+	}
+
+	return enabled, nil
+}
+
+//parseGenerators reads the generators of a manifest, named after their template unless named explicitly
+func parseGenerators(content []byte, manifestFile string) ([]*Generator, error) {
+	m := &manifest{}
+	if err := yaml.UnmarshalStrict(content, m); err != nil {
+		return nil, fmt.Errorf("Error parsing %s: %v", manifestFile, err)
+	}
+
+	for i, generator := range m.Generators {
+		if generator.Name == "" {
+			generator.Name = generator.Template
+		}
+		if generator.Name == "" {
+			return nil, fmt.Errorf("Generator %d of %s must specify a name or a template", i+1, manifestFile)
+		}
+	}
+	return m.Generators, nil
+}
+
+//mergeGenerators merges overrides into the generators with the same name, other overrides are appended
+func mergeGenerators(generators []*Generator, overrides []*Generator) []*Generator {
+	for _, override := range overrides {
+		found := false
+		for _, generator := range generators {
+			if generator.Name == override.Name {
+				generator.merge(override)
+				found = true
+				break
+			}
+		}
+		if !found {
+			generators = append(generators, override)
+		}
+	}
+	return generators
+}
+
+func (generator *Generator) merge(override *Generator) {
+	if override.Template != "" {
+		generator.Template = override.Template
+	}
+	if override.Target != "" {
+		generator.Target = override.Target
+	}
+	if override.ForEach != "" {
+		generator.ForEach = override.ForEach
+	}
+	if override.Mode != "" {
+		generator.Mode = override.Mode
+	}
+	generator.Disabled = override.Disabled
+}
+
+func (generator *Generator) setDefaults() {
+	if generator.ForEach == "" {
+		generator.ForEach = ForEachOnce
+	}
+	if generator.Mode == "" {
+		generator.Mode = "0644"
+	}
+}
+
+func (generator *Generator) validate() error {
+	if generator.Template == "" || generator.Target == "" {
+		return fmt.Errorf("'%s' must specify template and target", generator.Name)
+	}
+
+	switch generator.ForEach {
+	case ForEachOnce, ForEachOrg, ForEachPeer, ForEachChannel, ForEachChaincode:
+	default:
+		return fmt.Errorf("'%s' iterates over unsupported '%s', expected one of once, org, peer, channel, chaincode", generator.Name, generator.ForEach)
+	}
+
+	mode, err := strconv.ParseUint(generator.Mode, 8, 32)
+	if err != nil || mode > 0777 {
+		return fmt.Errorf("'%s' has invalid file mode '%s'", generator.Name, generator.Mode)
+	}
+	generator.FileMode = os.FileMode(mode)
+
+	return nil
+}
+
+//Export writes the stock templates to a directory, existing files are only replaced when overwrite is set
+func Export(dir string, overwrite bool) (written []string, skipped []string, err error) {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, nil, err
+	}
+
+	for _, name := range Names() {
+		target := filepath.Join(dir, name)
+		if _, err := os.Stat(target); err == nil && !overwrite {
+			skipped = append(skipped, name)
+			continue
+		}
+
+		content, err := stock.ReadFile(name)
+		if err != nil {
+			return written, skipped, err
+		}
+		if err := ioutil.WriteFile(target, content, 0644); err != nil {
+			return written, skipped, err
+		}
+		written = append(written, name)
+	}
+
+	return written, skipped, nil
+}